@@ -3,8 +3,12 @@ package main
 import (
 	"backend/pkg"
 	"backend/pkg/db"
+	"backend/pkg/handlers"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 )
 
 func main() {
@@ -17,6 +21,17 @@ func main() {
 		log.Fatal("Error applying migrations:", err)
 	}
 
+	// Allow overriding how deep comment threads may nest, e.g. COMMENT_MAX_DEPTH=5
+	if depth, err := strconv.Atoi(os.Getenv("COMMENT_MAX_DEPTH")); err == nil && depth > 0 {
+		db.MaxCommentDepth = depth
+	}
+
+	// Publish scheduled posts in the background
+	go handlers.StartPostScheduler(30 * time.Second)
+
+	// Delete expired stories and their images in the background
+	go handlers.StartStoryCleanup(10 * time.Minute)
+
 	// set up CORS middle ware
 	handler := pkg.SetupRouter()
 
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists   = errors.New("a collection with this name already exists")
+)
+
+// maxCollectionNameLength matches the column size of bookmark_collections.name
+const maxCollectionNameLength = 100
+
+type BookmarkCollection struct {
+	CollectionID int    `json:"collection_id"`
+	UserID       int    `json:"user_id"`
+	Name         string `json:"name"`
+	PostCount    int    `json:"post_count"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type SaveBookmarkRequest struct {
+	CollectionID *int `json:"collection_id"`
+}
+
+// ValidCollectionName trims the name and reports whether it can be used for a collection
+func ValidCollectionName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	return name, name != "" && len(name) <= maxCollectionNameLength
+}
+
+// SavePost bookmarks the post for the user, in the collection if one is given.
+// Saving a post that is already saved moves it to the given collection.
+func SavePost(userID, postID int, collectionID *int) error {
+	if collectionID != nil {
+		if err := checkCollectionOwner(*collectionID, userID); err != nil {
+			return err
+		}
+	}
+
+	_, err := DB.Exec(`INSERT INTO bookmarks (user_id, post_id, collection_id) VALUES (?, ?, ?)
+                       ON CONFLICT (user_id, post_id) DO UPDATE SET collection_id = excluded.collection_id`,
+		userID, postID, collectionID)
+	if err != nil {
+		log.Printf("Error inserting bookmark: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func UnsavePost(userID, postID int) error {
+	_, err := DB.Exec("DELETE FROM bookmarks WHERE user_id = ? AND post_id = ?", userID, postID)
+	if err != nil {
+		log.Printf("Error deleting bookmark: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetSavedPosts returns one page of the user's saved posts, most recently saved first, optionally
+// only those of one collection. Visibility is checked again here, so posts the user may no longer
+// see are left out.
+func GetSavedPosts(userID int, collectionID *int, limit, offset int) ([]Post, error) {
+	if collectionID != nil {
+		if err := checkCollectionOwner(*collectionID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at,
+                     (u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
+              FROM bookmarks b
+              JOIN posts p ON b.post_id = p.post_id
+              JOIN users u ON p.user_id = u.user_id
+              WHERE b.user_id = ? AND (? IS NULL OR b.collection_id = ?) AND ` + visiblePostCondition + `
+              ORDER BY b.created_at DESC, p.post_id DESC
+              LIMIT ? OFFSET ?`
+
+	args := append([]interface{}{userID, collectionID, collectionID}, visiblePostArgs(userID)...)
+	args = append(args, limit, offset)
+
+	posts, err := fetchPosts(query, args...)
+	if err != nil {
+		log.Printf("Error querying saved posts: %v", err)
+		return nil, err
+	}
+
+	if err := fillPostDetails(posts, userID); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// GetBookmarkCollections returns the user's collections with the number of posts saved in each
+func GetBookmarkCollections(userID int) ([]BookmarkCollection, error) {
+	query := `SELECT c.collection_id, c.user_id, c.name, c.created_at,
+                     (SELECT COUNT(*) FROM bookmarks b WHERE b.collection_id = c.collection_id)
+              FROM bookmark_collections c
+              WHERE c.user_id = ?
+              ORDER BY c.name COLLATE NOCASE`
+
+	rows, err := DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetBookmarkCollections: failed to query collections: %v", err)
+	}
+	defer rows.Close()
+
+	collections := []BookmarkCollection{}
+	for rows.Next() {
+		var collection BookmarkCollection
+		err := rows.Scan(&collection.CollectionID, &collection.UserID, &collection.Name, &collection.CreatedAt, &collection.PostCount)
+		if err != nil {
+			return nil, fmt.Errorf("GetBookmarkCollections: failed to scan collection row: %v", err)
+		}
+		collections = append(collections, collection)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetBookmarkCollections: error iterating over collection rows: %v", err)
+	}
+
+	return collections, nil
+}
+
+func CreateBookmarkCollection(userID int, name string) (int, error) {
+	result, err := DB.Exec("INSERT INTO bookmark_collections (user_id, name) VALUES (?, ?)", userID, name)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return 0, ErrCollectionExists
+		}
+		log.Printf("Error inserting bookmark collection: %v", err)
+		return 0, err
+	}
+
+	collectionID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("Error retrieving last insert ID: %v", err)
+		return 0, err
+	}
+
+	return int(collectionID), nil
+}
+
+func RenameBookmarkCollection(collectionID, userID int, name string) error {
+	if err := checkCollectionOwner(collectionID, userID); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec("UPDATE bookmark_collections SET name = ? WHERE collection_id = ?", name, collectionID)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return ErrCollectionExists
+		}
+		log.Printf("Error renaming bookmark collection: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteBookmarkCollection removes a collection. The posts saved in it stay saved as unsorted.
+func DeleteBookmarkCollection(collectionID, userID int) error {
+	if err := checkCollectionOwner(collectionID, userID); err != nil {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE bookmarks SET collection_id = NULL WHERE collection_id = ?", collectionID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error moving bookmarks out of collection: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM bookmark_collections WHERE collection_id = ?", collectionID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting bookmark collection: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkCollectionOwner returns ErrCollectionNotFound unless the collection belongs to the user
+func checkCollectionOwner(collectionID, userID int) error {
+	var exists bool
+	err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM bookmark_collections WHERE collection_id = ? AND user_id = ?)",
+		collectionID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("checkCollectionOwner: failed to query collection: %v", err)
+	}
+
+	if !exists {
+		return ErrCollectionNotFound
+	}
+
+	return nil
+}
+
+func isUniqueConstraintError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
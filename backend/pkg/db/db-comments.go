@@ -1,51 +1,151 @@
 package db
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 )
 
+// MaxCommentDepth is the number of nesting levels a comment thread may have.
+// Top level comments have depth 0, so replies can go down to depth MaxCommentDepth-1.
+var MaxCommentDepth = 3
+
+var (
+	ErrParentCommentNotFound = errors.New("parent comment not found on this post")
+	ErrCommentTooDeep        = errors.New("maximum reply depth reached")
+	ErrCommentsLocked        = errors.New("comments on this post are locked")
+	ErrCommentsDisabled      = errors.New("comments on this post are turned off")
+	ErrCommentsFollowersOnly = errors.New("only followers of the author can comment on this post")
+)
+
+// Comment policies a post author can choose from
+const (
+	CommentPolicyEveryone  = "everyone"
+	CommentPolicyFollowers = "followers"
+	CommentPolicyNobody    = "nobody"
+)
+
+type CommentSettings struct {
+	CommentPolicy  string `json:"comment_policy"`
+	CommentsLocked bool   `json:"comments_locked"`
+}
+
+type HideCommentRequest struct {
+	Hidden bool `json:"hidden"`
+}
+
+// Comment statuses. Hidden and deleted comments stay in the table as tombstones
+// so that their replies keep a parent.
+const (
+	CommentVisible = "visible"
+	CommentHidden  = "hidden"
+	CommentDeleted = "deleted"
+)
+
 type Comment struct {
-	CommentID    int       `json:"comment_id,omitempty"`
-	PostID       int       `json:"post_id"`
-	UserID       int       `json:"user_id,omitempty"`
-	Content      string    `json:"content"`
-	CommentImage *string   `json:"comment_image,omitempty"`
-	CreatedAt    time.Time `json:"created_at,omitempty"`
-	FullName     string    `json:"full_name"`
+	CommentID       int        `json:"comment_id,omitempty"`
+	PostID          int        `json:"post_id"`
+	UserID          int        `json:"user_id,omitempty"`
+	ParentCommentID *int       `json:"parent_comment_id,omitempty"`
+	Depth           int        `json:"depth"`
+	Content         string     `json:"content"`
+	CommentImage    *string    `json:"comment_image,omitempty"`
+	Media           []Media    `json:"media"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at,omitempty"`
+	EditedAt        *time.Time `json:"edited_at,omitempty"`
+	FullName        string     `json:"full_name"`
+	LikeCount       int        `json:"like_count"`
+	LikedByMe       bool       `json:"liked_by_me"`
+	ReplyCount      int        `json:"reply_count"`
 }
 
-func InsertComment(comment Comment) error {
-	statement, err := DB.Prepare(`INSERT INTO comments (post_id, user_id, content, comment_image) VALUES (?, ?, ?, ?)`)
+func InsertComment(comment Comment) (int, error) {
+	// Top level comments are at depth 0, replies one level below their parent, which has to be on the same post
+	comment.Depth = 0
+	if comment.ParentCommentID != nil {
+		var parentPostID, parentDepth int
+		err := DB.QueryRow("SELECT post_id, depth FROM comments WHERE comment_id = ? AND status != 'deleted'", *comment.ParentCommentID).Scan(&parentPostID, &parentDepth)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return 0, ErrParentCommentNotFound
+			}
+			log.Printf("Error querying parent comment: %v", err)
+			return 0, err
+		}
+
+		if parentPostID != comment.PostID {
+			return 0, ErrParentCommentNotFound
+		}
+
+		comment.Depth = parentDepth + 1
+		if comment.Depth >= MaxCommentDepth {
+			return 0, ErrCommentTooDeep
+		}
+	}
+
+	statement, err := DB.Prepare(`INSERT INTO comments (post_id, user_id, parent_comment_id, depth, content, comment_image) VALUES (?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		log.Printf("Error preparing insert statement for comment: %v", err)
-		return err
+		return 0, err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(comment.PostID, comment.UserID, comment.Content, comment.CommentImage)
+	result, err := statement.Exec(comment.PostID, comment.UserID, comment.ParentCommentID, comment.Depth, comment.Content, comment.CommentImage)
 	if err != nil {
 		log.Printf("Error executing insert statement for comment: %v", err)
-		return err
+		return 0, err
 	}
 
-	fmt.Println("Comment inserted to DB successfully", comment.CommentID)
+	commentID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("Error retrieving last insert ID: %v", err)
+		return 0, err
+	}
 
-	return nil
-}
+	fmt.Println("Comment inserted to DB successfully", commentID)
 
-func GetCommentsForPost(postID int) ([]Comment, error) {
-	var comments []Comment
+	err = insertCommentMedia(int(commentID), comment.Media)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(commentID), nil
+}
 
+// GetCommentsForPost returns the top level comments of a post, replies are fetched with GetCommentReplies
+func GetCommentsForPost(postID, viewerID int) ([]Comment, error) {
 	// Adjust the query to select comments and user names based on postID
-	query := `SELECT c.comment_id, c.post_id, c.user_id, c.content, c.comment_image, c.created_at, 
+	query := `SELECT c.comment_id, c.post_id, c.user_id, c.parent_comment_id, c.depth, c.content, c.comment_image, c.status, c.created_at, c.edited_at,
                      u.firstname || ' ' || u.lastname AS full_name
               FROM comments c
               JOIN users u ON c.user_id = u.user_id
-              WHERE c.post_id = ?`
+              WHERE c.post_id = ? AND c.parent_comment_id IS NULL
+              ORDER BY c.created_at ASC, c.comment_id ASC`
 
-	rows, err := DB.Query(query, postID)
+	return fetchComments(viewerID, query, postID)
+}
+
+// GetCommentReplies returns one page of direct replies to a comment, oldest first
+func GetCommentReplies(commentID, viewerID, limit, offset int) ([]Comment, error) {
+	query := `SELECT c.comment_id, c.post_id, c.user_id, c.parent_comment_id, c.depth, c.content, c.comment_image, c.status, c.created_at, c.edited_at,
+                     u.firstname || ' ' || u.lastname AS full_name
+              FROM comments c
+              JOIN users u ON c.user_id = u.user_id
+              WHERE c.parent_comment_id = ?
+              ORDER BY c.created_at ASC, c.comment_id ASC
+              LIMIT ? OFFSET ?`
+
+	return fetchComments(viewerID, query, commentID, limit, offset)
+}
+
+// fetchComments is a helper function to execute the provided query and fetch comments with their details
+func fetchComments(viewerID int, query string, args ...interface{}) ([]Comment, error) {
+	var comments []Comment
+
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		log.Printf("Error querying database for comments: %v", err)
 		return nil, err
@@ -56,7 +156,7 @@ func GetCommentsForPost(postID int) ([]Comment, error) {
 	for rows.Next() {
 		var comment Comment
 		// Adjust the Scan to include the full_name
-		err := rows.Scan(&comment.CommentID, &comment.PostID, &comment.UserID, &comment.Content, &comment.CommentImage, &comment.CreatedAt, &comment.FullName)
+		err := rows.Scan(&comment.CommentID, &comment.PostID, &comment.UserID, &comment.ParentCommentID, &comment.Depth, &comment.Content, &comment.CommentImage, &comment.Status, &comment.CreatedAt, &comment.EditedAt, &comment.FullName)
 		if err != nil {
 			log.Printf("Error scanning comment row: %v", err)
 			return nil, err
@@ -69,5 +169,238 @@ func GetCommentsForPost(postID int) ([]Comment, error) {
 		return nil, err
 	}
 
+	// Whether the viewer moderates a post only has to be looked up once per post
+	moderatedPosts := make(map[int]bool)
+	for i := range comments {
+		comment := &comments[i]
+
+		if err := attachCommentMedia(comment); err != nil {
+			return nil, err
+		}
+
+		if comment.Status == CommentHidden && comment.UserID != viewerID {
+			canModerate, ok := moderatedPosts[comment.PostID]
+			if !ok {
+				canModerate, err = CanModeratePostComments(comment.PostID, viewerID)
+				if err != nil {
+					return nil, err
+				}
+				moderatedPosts[comment.PostID] = canModerate
+			}
+			if !canModerate {
+				tombstoneComment(comment)
+			}
+		}
+
+		if comment.Status == CommentDeleted {
+			tombstoneComment(comment)
+		}
+
+		if err := fillCommentDetail(comment, viewerID); err != nil {
+			return nil, err
+		}
+	}
+
 	return comments, nil
 }
+
+// tombstoneComment strips everything but the position in the thread from a removed comment
+func tombstoneComment(comment *Comment) {
+	comment.UserID = 0
+	comment.FullName = ""
+	comment.Content = ""
+	comment.CommentImage = nil
+	comment.Media = []Media{}
+	comment.EditedAt = nil
+}
+
+// fillCommentDetail adds the viewer dependent details (likes, replies, ...) to a comment
+func fillCommentDetail(comment *Comment, viewerID int) error {
+	if err := attachCommentLikes(comment, viewerID); err != nil {
+		return err
+	}
+
+	err := DB.QueryRow("SELECT COUNT(*) FROM comments WHERE parent_comment_id = ?", comment.CommentID).Scan(&comment.ReplyCount)
+	if err != nil {
+		log.Printf("Error counting replies for comment %d: %v", comment.CommentID, err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCommentPostID returns the ID of the post a comment belongs to
+func GetCommentPostID(commentID int) (int, error) {
+	var postID int
+	err := DB.QueryRow("SELECT post_id FROM comments WHERE comment_id = ?", commentID).Scan(&postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("GetCommentPostID: comment with ID %d not found", commentID)
+		}
+		return 0, err
+	}
+
+	return postID, nil
+}
+
+// GetCommentByID returns a single comment as stored, without hiding removed content
+func GetCommentByID(commentID int) (Comment, error) {
+	var comment Comment
+
+	query := `SELECT c.comment_id, c.post_id, c.user_id, c.parent_comment_id, c.depth, c.content, c.comment_image, c.status, c.created_at, c.edited_at,
+                     u.firstname || ' ' || u.lastname AS full_name
+              FROM comments c
+              JOIN users u ON c.user_id = u.user_id
+              WHERE c.comment_id = ?`
+
+	err := DB.QueryRow(query, commentID).Scan(&comment.CommentID, &comment.PostID, &comment.UserID, &comment.ParentCommentID, &comment.Depth, &comment.Content, &comment.CommentImage, &comment.Status, &comment.CreatedAt, &comment.EditedAt, &comment.FullName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Comment{}, fmt.Errorf("GetCommentByID: comment with ID %d not found", commentID)
+		}
+		return Comment{}, err
+	}
+
+	return comment, nil
+}
+
+func UpdateCommentContent(commentID int, content string) error {
+	_, err := DB.Exec("UPDATE comments SET content = ?, edited_at = CURRENT_TIMESTAMP WHERE comment_id = ?", content, commentID)
+	if err != nil {
+		log.Printf("Error updating comment: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteComment turns the comment into a tombstone and removes its media. moderatorID is recorded
+// when someone other than the author removes it. It returns the URLs of the comment's images, so
+// the caller can remove the files.
+func DeleteComment(commentID int, moderatorID *int) ([]string, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+
+	// The file URLs are collected before their rows go
+	fileURLs, err := mediaURLs(tx, "comment_id = ?", commentID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM media WHERE comment_id = ?", commentID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting comment media: %v", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(`UPDATE comments SET status = 'deleted', content = '', comment_image = NULL, moderated_by = ?
+                      WHERE comment_id = ?`, moderatorID, commentID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting comment: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return fileURLs, nil
+}
+
+// SetCommentHidden hides a comment from everyone except its author and the post's moderators, or shows it again
+func SetCommentHidden(commentID, moderatorID int, hidden bool) error {
+	status := CommentVisible
+	if hidden {
+		status = CommentHidden
+	}
+
+	_, err := DB.Exec("UPDATE comments SET status = ?, moderated_by = ? WHERE comment_id = ? AND status != 'deleted'", status, moderatorID, commentID)
+	if err != nil {
+		log.Printf("Error updating comment status: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// CanModeratePostComments reports whether the user may delete or hide other people's comments on a post.
+// That is the post author and, for group posts, the group moderators, admins and owner.
+func CanModeratePostComments(postID, userID int) (bool, error) {
+	var authorID int
+	var groupID sql.NullInt64
+	err := DB.QueryRow("SELECT user_id, group_id FROM posts WHERE post_id = ?", postID).Scan(&authorID, &groupID)
+	if err != nil {
+		log.Printf("Error querying post author: %v", err)
+		return false, err
+	}
+
+	if authorID == userID {
+		return true, nil
+	}
+
+	if groupID.Valid {
+		return HasGroupRole(int(groupID.Int64), userID, GroupRoleModerator)
+	}
+
+	return false, nil
+}
+
+func ValidCommentPolicy(policy string) bool {
+	switch policy {
+	case CommentPolicyEveryone, CommentPolicyFollowers, CommentPolicyNobody:
+		return true
+	}
+	return false
+}
+
+// CanCommentOnPost checks the post's comment settings for a user who can already see the post.
+// It returns one of the ErrComments errors if the user may not comment. The author always can.
+func CanCommentOnPost(postID, userID int) error {
+	var authorID int
+	var settings CommentSettings
+	err := DB.QueryRow("SELECT user_id, comment_policy, comments_locked FROM posts WHERE post_id = ?", postID).Scan(&authorID, &settings.CommentPolicy, &settings.CommentsLocked)
+	if err != nil {
+		log.Printf("Error querying comment settings: %v", err)
+		return err
+	}
+
+	if authorID == userID {
+		return nil
+	}
+
+	if settings.CommentsLocked {
+		return ErrCommentsLocked
+	}
+
+	switch settings.CommentPolicy {
+	case CommentPolicyNobody:
+		return ErrCommentsDisabled
+	case CommentPolicyFollowers:
+		status, err := GetFollowStatus(userID, authorID)
+		if err != nil {
+			return err
+		}
+		if status != "accepted" {
+			return ErrCommentsFollowersOnly
+		}
+	}
+
+	return nil
+}
+
+// UpdateCommentSettings changes who may comment on a post and whether comments are locked
+func UpdateCommentSettings(postID int, settings CommentSettings) error {
+	_, err := DB.Exec("UPDATE posts SET comment_policy = ?, comments_locked = ? WHERE post_id = ?", settings.CommentPolicy, settings.CommentsLocked, postID)
+	if err != nil {
+		log.Printf("Error updating comment settings: %v", err)
+		return err
+	}
+
+	return nil
+}
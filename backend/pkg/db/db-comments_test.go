@@ -0,0 +1,30 @@
+package db
+
+import "testing"
+
+func TestDeleteCommentReturnsMediaURLs(t *testing.T) {
+	author := newTestUser(t, true)
+	postID := newTestPost(t, author, 0, "public")
+
+	url := "http://localhost:8000/uploads/comment-image/deleted.png"
+	commentID, err := InsertComment(Comment{PostID: postID, UserID: author, Content: "Comment with an image", Media: []Media{{URL: url}}})
+	if err != nil {
+		t.Fatalf("inserting comment: %v", err)
+	}
+
+	fileURLs, err := DeleteComment(commentID, nil)
+	if err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if len(fileURLs) != 1 || fileURLs[0] != url {
+		t.Errorf("DeleteComment returned %v, want [%s]", fileURLs, url)
+	}
+
+	var remaining int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM media WHERE comment_id = ?", commentID).Scan(&remaining); err != nil {
+		t.Fatalf("counting media: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("%d media rows left on the deleted comment", remaining)
+	}
+}
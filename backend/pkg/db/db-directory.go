@@ -0,0 +1,113 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Relationship filters of the user directory
+const (
+	DirectoryFilterFollowers    = "followers"
+	DirectoryFilterFollowing    = "following"
+	DirectoryFilterMutuals      = "mutuals"
+	DirectoryFilterNotFollowing = "not_following"
+)
+
+// DirectoryUser is one row of the user directory. FollowStatus is the viewer's follow of the user
+// with the same values as GetFollowStatus, FollowsMe tells if the user follows the viewer.
+type DirectoryUser struct {
+	UserID        int    `json:"user_id"`
+	Firstname     string `json:"firstname"`
+	Lastname      string `json:"lastname"`
+	Nickname      string `json:"nickname"`
+	Avatar        string `json:"avatar"`
+	ProfilePublic bool   `json:"profile_public"`
+	FollowStatus  string `json:"follow_status"`
+	FollowsMe     bool   `json:"follows_me"`
+}
+
+type UserDirectory struct {
+	Total int             `json:"total"`
+	Users []DirectoryUser `json:"users"`
+}
+
+// directoryFilterConditions match the users (aliased u) in a relationship with the viewer.
+// Each expects the viewer's user ID bound once.
+var directoryFilterConditions = map[string]string{
+	DirectoryFilterFollowers: `EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = u.user_id AND f.following_id = ? AND f.status = 'accepted')`,
+	DirectoryFilterFollowing: `EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = u.user_id AND f.status = 'accepted')`,
+	DirectoryFilterMutuals: `EXISTS (SELECT 1 FROM follows f1 JOIN follows f2 ON f2.follower_id = f1.following_id AND f2.following_id = f1.follower_id
+		WHERE f1.follower_id = ? AND f1.following_id = u.user_id AND f1.status = 'accepted' AND f2.status = 'accepted')`,
+	DirectoryFilterNotFollowing: `NOT EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = u.user_id AND f.status IN ('accepted', 'pending'))`,
+}
+
+func ValidDirectoryFilter(filter string) bool {
+	_, ok := directoryFilterConditions[filter]
+	return filter == "" || ok
+}
+
+// GetUserDirectory returns one page of the other users, ordered by name. A non-empty prefix
+// narrows it to users whose first name, last name, full name or nickname starts with it.
+func GetUserDirectory(viewerID int, prefix, filter string, limit, offset int) (UserDirectory, error) {
+	directory := UserDirectory{Users: []DirectoryUser{}}
+
+	where := "u.user_id != ?"
+	args := []interface{}{viewerID}
+
+	if prefix != "" {
+		pattern := escapeLike(strings.ToLower(prefix)) + "%"
+		where += ` AND (LOWER(u.firstname) LIKE ? ESCAPE '\'
+			OR LOWER(u.lastname) LIKE ? ESCAPE '\'
+			OR LOWER(u.firstname || ' ' || u.lastname) LIKE ? ESCAPE '\'
+			OR LOWER(COALESCE(u.nickname, '')) LIKE ? ESCAPE '\')`
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+
+	if condition, ok := directoryFilterConditions[filter]; ok {
+		where += " AND " + condition
+		args = append(args, viewerID)
+	}
+
+	err := DB.QueryRow("SELECT COUNT(*) FROM users u WHERE "+where, args...).Scan(&directory.Total)
+	if err != nil {
+		return UserDirectory{}, fmt.Errorf("GetUserDirectory: failed to count users: %v", err)
+	}
+
+	query := `SELECT u.user_id, u.firstname, u.lastname, COALESCE(u.nickname, ''), COALESCE(u.avatar, ''), u.profile_public,
+                     COALESCE((SELECT f.status FROM follows f WHERE f.follower_id = ? AND f.following_id = u.user_id), 'rejected'),
+                     EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = u.user_id AND f.following_id = ? AND f.status = 'accepted')
+              FROM users u
+              WHERE ` + where + `
+              ORDER BY LOWER(u.firstname), LOWER(u.lastname), u.user_id
+              LIMIT ? OFFSET ?`
+
+	queryArgs := append([]interface{}{viewerID, viewerID}, args...)
+	queryArgs = append(queryArgs, limit, offset)
+
+	rows, err := DB.Query(query, queryArgs...)
+	if err != nil {
+		return UserDirectory{}, fmt.Errorf("GetUserDirectory: failed to query users: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user DirectoryUser
+		err := rows.Scan(&user.UserID, &user.Firstname, &user.Lastname, &user.Nickname, &user.Avatar, &user.ProfilePublic,
+			&user.FollowStatus, &user.FollowsMe)
+		if err != nil {
+			return UserDirectory{}, fmt.Errorf("GetUserDirectory: failed to scan user row: %v", err)
+		}
+		directory.Users = append(directory.Users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return UserDirectory{}, fmt.Errorf("GetUserDirectory: error iterating over user rows: %v", err)
+	}
+
+	return directory, nil
+}
+
+// escapeLike escapes the LIKE wildcards of user input, for patterns using ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -82,6 +82,17 @@ func GetEvents(groupID int) ([]Event, error) {
 	return events, nil
 }
 
+// GetEventGroupID returns the group an event belongs to, or sql.ErrNoRows if there is no such event
+func GetEventGroupID(eventID int) (int, error) {
+	var groupID int
+	err := DB.QueryRow("SELECT group_id FROM events WHERE event_id = ?", eventID).Scan(&groupID)
+	if err != nil {
+		return 0, err
+	}
+
+	return groupID, nil
+}
+
 func UpdateAttendeesStatus(attendeeID int, eventID int, status string) error {
 	// Check if the attendee's status already exists in the database
 	var existingStatus string
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Actions recorded in the group moderation log
+const (
+	ModerationRemove = "remove"
+	ModerationBan    = "ban"
+	ModerationUnban  = "unban"
+)
+
+const maxModerationReasonLength = 500
+
+var (
+	ErrUserBanned    = errors.New("user is banned from the group")
+	ErrUserNotBanned = errors.New("user is not banned from the group")
+)
+
+// GroupModerationRequest names the member to remove, ban or unban
+type GroupModerationRequest struct {
+	UserID int    `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type GroupBan struct {
+	UserID       int       `json:"user_id"`
+	FullName     string    `json:"full_name"`
+	Avatar       string    `json:"avatar"`
+	BannedBy     int       `json:"banned_by"`
+	BannedByName string    `json:"banned_by_name"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type GroupModerationEntry struct {
+	LogID      int       `json:"log_id"`
+	ActorID    int       `json:"actor_id"`
+	ActorName  string    `json:"actor_name"`
+	TargetID   int       `json:"target_id"`
+	TargetName string    `json:"target_name"`
+	Action     string    `json:"action"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ValidModerationReason trims a removal or ban reason and checks its length
+func ValidModerationReason(reason string) (string, bool) {
+	reason = strings.TrimSpace(reason)
+	return reason, len(reason) <= maxModerationReasonLength
+}
+
+// IsBannedFromGroup reports whether the user is banned from the group
+func IsBannedFromGroup(groupID, userID int) (bool, error) {
+	var banned bool
+	err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM group_bans WHERE group_id = ? AND user_id = ?)", groupID, userID).Scan(&banned)
+	if err != nil {
+		return false, fmt.Errorf("IsBannedFromGroup: failed to check ban: %v", err)
+	}
+
+	return banned, nil
+}
+
+// RemoveGroupMember ends the user's membership, join request or invitation and records who removed them.
+// With ban set the user is also banned, which works for users who aren't in the group as well.
+// The user's drafts and scheduled posts in the group are cancelled, the URLs of their images are
+// returned so the caller can remove the files.
+func RemoveGroupMember(groupID, actorID, targetID int, reason string, ban bool) ([]string, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+
+	// Pending requests and invitations are closed too, so the user can't get back in through them
+	result, err := tx.Exec("UPDATE group_members SET status = 'rejected', role = 'member' WHERE group_id = ? AND user_id = ? AND status != 'rejected'",
+		groupID, targetID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error removing group member: %v", err)
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, err
+	}
+
+	action := ModerationRemove
+	if ban {
+		action = ModerationBan
+
+		_, err = tx.Exec("INSERT OR REPLACE INTO group_bans (group_id, user_id, banned_by, reason) VALUES (?, ?, ?, ?)",
+			groupID, targetID, actorID, reason)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			log.Printf("Error inserting group ban: %v", err)
+			return nil, err
+		}
+	} else if affected == 0 {
+		tx.Rollback()
+		return nil, ErrNotGroupMember
+	}
+
+	fileURLs, err := cancelUnpublishedGroupPosts(tx, groupID, targetID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, err
+	}
+
+	err = logGroupModeration(tx, groupID, actorID, targetID, action, reason)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := removeUserFromGroupChat(groupID, targetID); err != nil {
+		return nil, err
+	}
+
+	return fileURLs, nil
+}
+
+// UnbanGroupMember lifts a ban, the user can then ask to join or be invited again
+func UnbanGroupMember(groupID, actorID, targetID int) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM group_bans WHERE group_id = ? AND user_id = ?", groupID, targetID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting group ban: %v", err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return err
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return ErrUserNotBanned
+	}
+
+	err = logGroupModeration(tx, groupID, actorID, targetID, ModerationUnban, "")
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// cancelUnpublishedGroupPosts deletes the drafts and scheduled posts of a user in a group and
+// returns the URLs of their images
+func cancelUnpublishedGroupPosts(tx *sql.Tx, groupID, userID int) ([]string, error) {
+	rows, err := tx.Query("SELECT post_id FROM posts WHERE group_id = ? AND user_id = ? AND status != 'published'", groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cancelUnpublishedGroupPosts: failed to query posts: %v", err)
+	}
+
+	var postIDs []int
+	for rows.Next() {
+		var postID int
+		if err := rows.Scan(&postID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("cancelUnpublishedGroupPosts: failed to scan post row: %v", err)
+		}
+		postIDs = append(postIDs, postID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cancelUnpublishedGroupPosts: error iterating over post rows: %v", err)
+	}
+
+	var fileURLs []string
+	for _, postID := range postIDs {
+		urls, err := deletePost(tx, postID)
+		if err != nil {
+			return nil, err
+		}
+		fileURLs = append(fileURLs, urls...)
+	}
+
+	return fileURLs, nil
+}
+
+func logGroupModeration(tx *sql.Tx, groupID, actorID, targetID int, action, reason string) error {
+	_, err := tx.Exec("INSERT INTO group_moderation_log (group_id, actor_id, target_id, action, reason) VALUES (?, ?, ?, ?, ?)",
+		groupID, actorID, targetID, action, reason)
+	if err != nil {
+		log.Printf("Error inserting group moderation log entry: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetGroupBans lists the users banned from a group, the latest bans first
+func GetGroupBans(groupID int) ([]GroupBan, error) {
+	query := `SELECT u.user_id, (u.firstname || ' ' || u.lastname), COALESCE(u.avatar, ''),
+	                 b.banned_by, (a.firstname || ' ' || a.lastname), b.reason, b.created_at
+	          FROM group_bans b
+	          JOIN users u ON b.user_id = u.user_id
+	          JOIN users a ON b.banned_by = a.user_id
+	          WHERE b.group_id = ?
+	          ORDER BY b.created_at DESC`
+
+	rows, err := DB.Query(query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("GetGroupBans: failed to query bans: %v", err)
+	}
+	defer rows.Close()
+
+	bans := []GroupBan{}
+	for rows.Next() {
+		var ban GroupBan
+		err := rows.Scan(&ban.UserID, &ban.FullName, &ban.Avatar, &ban.BannedBy, &ban.BannedByName, &ban.Reason, &ban.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("GetGroupBans: failed to scan ban row: %v", err)
+		}
+		bans = append(bans, ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetGroupBans: error iterating over ban rows: %v", err)
+	}
+
+	return bans, nil
+}
+
+// GetGroupModerationLog returns the moderation history of a group, the latest entries first
+func GetGroupModerationLog(groupID, limit, offset int) ([]GroupModerationEntry, error) {
+	query := `SELECT l.log_id, l.actor_id, (a.firstname || ' ' || a.lastname), l.target_id, (t.firstname || ' ' || t.lastname),
+	                 l.action, l.reason, l.created_at
+	          FROM group_moderation_log l
+	          JOIN users a ON l.actor_id = a.user_id
+	          JOIN users t ON l.target_id = t.user_id
+	          WHERE l.group_id = ?
+	          ORDER BY l.created_at DESC, l.log_id DESC
+	          LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, groupID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("GetGroupModerationLog: failed to query log: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []GroupModerationEntry{}
+	for rows.Next() {
+		var entry GroupModerationEntry
+		err := rows.Scan(&entry.LogID, &entry.ActorID, &entry.ActorName, &entry.TargetID, &entry.TargetName,
+			&entry.Action, &entry.Reason, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("GetGroupModerationLog: failed to scan log row: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetGroupModerationLog: error iterating over log rows: %v", err)
+	}
+
+	return entries, nil
+}
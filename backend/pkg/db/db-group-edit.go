@@ -0,0 +1,179 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const maxGroupFieldLength = 255
+
+// GroupUpdate holds the new title and description of a group. CoverImage is left out to keep
+// the current cover, an empty string removes it and image data replaces it.
+type GroupUpdate struct {
+	Title      string  `json:"title"`
+	Content    string  `json:"content"`
+	CoverImage *string `json:"cover_image"`
+}
+
+// ValidateGroupUpdate trims the title and description and checks they fit their columns
+func ValidateGroupUpdate(update *GroupUpdate) error {
+	update.Title = strings.TrimSpace(update.Title)
+	update.Content = strings.TrimSpace(update.Content)
+
+	if update.Title == "" || update.Content == "" {
+		return errors.New("title and description are required")
+	}
+	if len(update.Title) > maxGroupFieldLength || len(update.Content) > maxGroupFieldLength {
+		return fmt.Errorf("title and description can be at most %d characters", maxGroupFieldLength)
+	}
+
+	return nil
+}
+
+// UpdateGroup saves the new metadata of a group, update.CoverImage must already be the URL of
+// the saved image. It returns the URL of the previous cover image when it was replaced or removed.
+func UpdateGroup(groupID int, update GroupUpdate) (*string, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+
+	var oldCover *string
+	err = tx.QueryRow("SELECT cover_image FROM groups WHERE group_id = ?", groupID).Scan(&oldCover)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, fmt.Errorf("UpdateGroup: failed to fetch group: %v", err)
+	}
+
+	if update.CoverImage == nil {
+		_, err = tx.Exec("UPDATE groups SET title = ?, content = ? WHERE group_id = ?", update.Title, update.Content, groupID)
+	} else {
+		// An empty string removes the cover
+		var cover *string
+		if *update.CoverImage != "" {
+			cover = update.CoverImage
+		}
+		_, err = tx.Exec("UPDATE groups SET title = ?, content = ?, cover_image = ? WHERE group_id = ?",
+			update.Title, update.Content, cover, groupID)
+	}
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error updating group: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if update.CoverImage == nil {
+		return nil, nil
+	}
+	return oldCover, nil
+}
+
+// groupDeleteStatements remove everything that belongs to a group, the group ID is bound to every one
+var groupDeleteStatements = []string{
+	// Notifications about the group and about its posts
+	`DELETE FROM notifications WHERE type IN ('group_invitation', 'join_group_request', 'join_group_response', 'new_event', 'group_post_rejected')
+	     AND reference_id = ?`,
+	`DELETE FROM notifications WHERE type IN ('post_like', 'post_comment', 'post_mention', 'post_published', 'comment_like', 'comment_reply', 'comment_mention',
+	                                          'group_post_approved')
+	     AND reference_id IN (SELECT post_id FROM posts WHERE group_id = ?)`,
+	"DELETE FROM notifications WHERE type = 'chat_mention' AND reference_id IN (SELECT chat_id FROM chats WHERE group_id = ?)",
+
+	// Group posts, as in DeletePost
+	"DELETE FROM post_viewers WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM post_likes WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM comment_likes WHERE comment_id IN (SELECT comment_id FROM comments WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?))",
+	"DELETE FROM media WHERE comment_id IN (SELECT comment_id FROM comments WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?))",
+	"DELETE FROM media WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM comments WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM post_tags WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM bookmarks WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM poll_votes WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM poll_options WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM polls WHERE post_id IN (SELECT post_id FROM posts WHERE group_id = ?)",
+	"DELETE FROM posts WHERE group_id = ?",
+	"DELETE FROM group_posts WHERE group_id = ?",
+
+	// Events
+	"DELETE FROM event_attendees WHERE event_id IN (SELECT event_id FROM events WHERE group_id = ?)",
+	"DELETE FROM events WHERE group_id = ?",
+
+	// The group chat
+	"DELETE FROM unread_messages WHERE chat_id IN (SELECT chat_id FROM chats WHERE group_id = ?)",
+	"DELETE FROM latest_read_messages WHERE chat_id IN (SELECT chat_id FROM chats WHERE group_id = ?)",
+	"DELETE FROM messages WHERE chat_id IN (SELECT chat_id FROM chats WHERE group_id = ?)",
+	"DELETE FROM chat_participants WHERE chat_id IN (SELECT chat_id FROM chats WHERE group_id = ?)",
+	"DELETE FROM chats WHERE group_id = ?",
+
+	// Invite links
+	"DELETE FROM group_invite_link_uses WHERE link_id IN (SELECT link_id FROM group_invite_links WHERE group_id = ?)",
+	"DELETE FROM group_invite_links WHERE group_id = ?",
+
+	// The file library, the stored files are removed by the caller
+	"DELETE FROM group_files WHERE group_id = ?",
+
+	// Members and moderation
+	"DELETE FROM group_members WHERE group_id = ?",
+	"DELETE FROM group_bans WHERE group_id = ?",
+	"DELETE FROM group_moderation_log WHERE group_id = ?",
+	"DELETE FROM groups WHERE group_id = ?",
+}
+
+// DeleteGroup removes a group with its members, posts, events, chat and notifications in one
+// transaction. It returns the URLs of the uploaded images that belonged to the group, so the
+// caller can remove the files.
+func DeleteGroup(groupID int) ([]string, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+
+	// The file URLs are collected before their rows go
+	rows, err := tx.Query(`SELECT cover_image FROM groups WHERE group_id = ? AND cover_image IS NOT NULL
+	                       UNION SELECT m.url FROM media m JOIN posts p ON m.post_id = p.post_id WHERE p.group_id = ?
+	                       UNION SELECT m.url FROM media m JOIN comments c ON m.comment_id = c.comment_id
+	                             JOIN posts p ON c.post_id = p.post_id WHERE p.group_id = ?`,
+		groupID, groupID, groupID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, fmt.Errorf("DeleteGroup: failed to query group files: %v", err)
+	}
+
+	var fileURLs []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			rows.Close()
+			tx.Rollback() // Roll back in case of error
+			return nil, fmt.Errorf("DeleteGroup: failed to scan file row: %v", err)
+		}
+		fileURLs = append(fileURLs, url)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, fmt.Errorf("DeleteGroup: error iterating over file rows: %v", err)
+	}
+
+	for _, statement := range groupDeleteStatements {
+		if _, err := tx.Exec(statement, groupID); err != nil {
+			tx.Rollback() // Roll back in case of error
+			log.Printf("Error deleting group %d: %v", groupID, err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return fileURLs, nil
+}
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxGroupFileSize is the largest file that can be uploaded to a group, in bytes
+	MaxGroupFileSize = 10 << 20
+	// GroupStorageQuota is how much all the files of a group may take up together, in bytes
+	GroupStorageQuota = 200 << 20
+
+	maxGroupFileNameLength = 255
+)
+
+var (
+	ErrGroupFileNotFound  = errors.New("file not found")
+	ErrGroupQuotaExceeded = errors.New("the group has no storage left for this file")
+)
+
+// groupFileTypes maps the MIME types that can be uploaded to the extension the files are stored with
+var groupFileTypes = map[string]string{
+	"application/pdf":    ".pdf",
+	"application/msword": ".doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/vnd.ms-excel": ".xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.ms-powerpoint":                                             ".ppt",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+	"text/csv":        ".csv",
+	"text/markdown":   ".md",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+}
+
+// UploadGroupFileRequest carries a file as a data URL, like the images of posts
+type UploadGroupFileRequest struct {
+	FileName string `json:"file_name"`
+	Data     string `json:"data"`
+}
+
+type GroupFile struct {
+	FileID       int       `json:"file_id"`
+	GroupID      int       `json:"group_id"`
+	UserID       int       `json:"user_id"`
+	UploaderName string    `json:"uploader_name"`
+	FileName     string    `json:"file_name"`
+	StoredName   string    `json:"-"`
+	MimeType     string    `json:"mime_type"`
+	Size         int64     `json:"size"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type GroupFileLibrary struct {
+	Files      []GroupFile `json:"files"`
+	UsedBytes  int64       `json:"used_bytes"`
+	QuotaBytes int64       `json:"quota_bytes"`
+}
+
+// GroupFileExtension returns the extension files of the MIME type are stored with, or false
+// if the type can't be uploaded
+func GroupFileExtension(mimeType string) (string, bool) {
+	extension, ok := groupFileTypes[mimeType]
+	return extension, ok
+}
+
+// CleanGroupFileName strips any directories from an uploaded file name and checks its length
+func CleanGroupFileName(fileName string) (string, error) {
+	fileName = strings.TrimSpace(filepath.Base(strings.ReplaceAll(fileName, "\\", "/")))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		return "", errors.New("file name is required")
+	}
+	if len(fileName) > maxGroupFileNameLength {
+		return "", fmt.Errorf("file name can be at most %d characters", maxGroupFileNameLength)
+	}
+
+	return fileName, nil
+}
+
+// InsertGroupFile records an uploaded file if the group has room for it, otherwise it returns
+// ErrGroupQuotaExceeded. The check and the insert share a transaction so parallel uploads can't
+// go over the quota together.
+func InsertGroupFile(file GroupFile) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return 0, err
+	}
+
+	var used int64
+	err = tx.QueryRow("SELECT COALESCE(SUM(size), 0) FROM group_files WHERE group_id = ?", file.GroupID).Scan(&used)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return 0, fmt.Errorf("InsertGroupFile: failed to sum file sizes: %v", err)
+	}
+	if used+file.Size > GroupStorageQuota {
+		tx.Rollback()
+		return 0, ErrGroupQuotaExceeded
+	}
+
+	result, err := tx.Exec("INSERT INTO group_files (group_id, user_id, file_name, stored_name, mime_type, size) VALUES (?, ?, ?, ?, ?, ?)",
+		file.GroupID, file.UserID, file.FileName, file.StoredName, file.MimeType, file.Size)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error inserting group file: %v", err)
+		return 0, err
+	}
+
+	fileID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error retrieving last insert ID: %v", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(fileID), nil
+}
+
+// GetGroupFileLibrary returns one page of the files of a group, the newest first, and how much of
+// the quota they use
+func GetGroupFileLibrary(groupID, limit, offset int) (GroupFileLibrary, error) {
+	library := GroupFileLibrary{Files: []GroupFile{}, QuotaBytes: GroupStorageQuota}
+
+	err := DB.QueryRow("SELECT COALESCE(SUM(size), 0) FROM group_files WHERE group_id = ?", groupID).Scan(&library.UsedBytes)
+	if err != nil {
+		return GroupFileLibrary{}, fmt.Errorf("GetGroupFileLibrary: failed to sum file sizes: %v", err)
+	}
+
+	query := `SELECT f.file_id, f.group_id, f.user_id, (u.firstname || ' ' || u.lastname), f.file_name, f.stored_name,
+	                 f.mime_type, f.size, f.created_at
+	          FROM group_files f
+	          JOIN users u ON f.user_id = u.user_id
+	          WHERE f.group_id = ?
+	          ORDER BY f.created_at DESC, f.file_id DESC
+	          LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, groupID, limit, offset)
+	if err != nil {
+		return GroupFileLibrary{}, fmt.Errorf("GetGroupFileLibrary: failed to query files: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var file GroupFile
+		err := rows.Scan(&file.FileID, &file.GroupID, &file.UserID, &file.UploaderName, &file.FileName, &file.StoredName,
+			&file.MimeType, &file.Size, &file.CreatedAt)
+		if err != nil {
+			return GroupFileLibrary{}, fmt.Errorf("GetGroupFileLibrary: failed to scan file row: %v", err)
+		}
+		library.Files = append(library.Files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return GroupFileLibrary{}, fmt.Errorf("GetGroupFileLibrary: error iterating over file rows: %v", err)
+	}
+
+	return library, nil
+}
+
+// GetGroupFile returns a file of a group library, or ErrGroupFileNotFound if there is no such file
+func GetGroupFile(fileID int) (GroupFile, error) {
+	var file GroupFile
+	err := DB.QueryRow(`SELECT f.file_id, f.group_id, f.user_id, (u.firstname || ' ' || u.lastname), f.file_name, f.stored_name,
+	                           f.mime_type, f.size, f.created_at
+	                    FROM group_files f
+	                    JOIN users u ON f.user_id = u.user_id
+	                    WHERE f.file_id = ?`, fileID).
+		Scan(&file.FileID, &file.GroupID, &file.UserID, &file.UploaderName, &file.FileName, &file.StoredName,
+			&file.MimeType, &file.Size, &file.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return GroupFile{}, ErrGroupFileNotFound
+		}
+		return GroupFile{}, fmt.Errorf("GetGroupFile: failed to fetch file: %v", err)
+	}
+
+	return file, nil
+}
+
+// DeleteGroupFile removes the record of a file, the caller removes the stored file
+func DeleteGroupFile(fileID int) error {
+	result, err := DB.Exec("DELETE FROM group_files WHERE file_id = ?", fileID)
+	if err != nil {
+		log.Printf("Error deleting group file: %v", err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrGroupFileNotFound
+	}
+
+	return nil
+}
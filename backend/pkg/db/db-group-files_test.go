@@ -0,0 +1,34 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInsertGroupFileQuota(t *testing.T) {
+	owner := newTestUser(t, true)
+	groupID, _ := newTestGroup(t, owner, GroupClosed)
+
+	file := func(name string, size int64) GroupFile {
+		return GroupFile{GroupID: groupID, UserID: owner, FileName: name, StoredName: fmt.Sprintf("%d-%s", groupID, name),
+			MimeType: "text/plain", Size: size}
+	}
+
+	if _, err := InsertGroupFile(file("large.txt", GroupStorageQuota-10)); err != nil {
+		t.Fatalf("InsertGroupFile under the quota: %v", err)
+	}
+	if _, err := InsertGroupFile(file("small.txt", 10)); err != nil {
+		t.Fatalf("InsertGroupFile filling the quota: %v", err)
+	}
+	if _, err := InsertGroupFile(file("extra.txt", 1)); err != ErrGroupQuotaExceeded {
+		t.Errorf("InsertGroupFile over the quota = %v, want ErrGroupQuotaExceeded", err)
+	}
+
+	library, err := GetGroupFileLibrary(groupID, 50, 0)
+	if err != nil {
+		t.Fatalf("GetGroupFileLibrary: %v", err)
+	}
+	if library.UsedBytes != GroupStorageQuota || len(library.Files) != 2 {
+		t.Errorf("library uses %d bytes in %d files, want %d in 2", library.UsedBytes, len(library.Files), GroupStorageQuota)
+	}
+}
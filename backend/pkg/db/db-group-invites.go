@@ -0,0 +1,278 @@
+package db
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrInviteLinkNotFound = errors.New("invite link not found")
+	ErrInviteLinkInvalid  = errors.New("invite link has expired, was revoked or was used up")
+	ErrAlreadyGroupMember = errors.New("user is already a member of the group")
+)
+
+// CreateInviteLinkRequest sets the optional limits of a new invite link
+type CreateInviteLinkRequest struct {
+	MaxUses   *int       `json:"max_uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RevokeInviteLinkRequest struct {
+	LinkID int `json:"link_id"`
+}
+
+type GroupInviteLink struct {
+	LinkID        int        `json:"link_id"`
+	GroupID       int        `json:"group_id"`
+	Token         string     `json:"token"`
+	CreatedBy     int        `json:"created_by"`
+	CreatedByName string     `json:"created_by_name"`
+	MaxUses       *int       `json:"max_uses"`
+	UseCount      int        `json:"use_count"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	// Active tells whether the link can still be used
+	Active bool `json:"active"`
+}
+
+// GroupInviteLinkUse records a user joining through an invite link
+type GroupInviteLinkUse struct {
+	UseID     int       `json:"use_id"`
+	LinkID    int       `json:"link_id"`
+	UserID    int       `json:"user_id"`
+	FullName  string    `json:"full_name"`
+	Avatar    string    `json:"avatar"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// inviteLinkActiveCondition matches the invite links (aliased l) that can still be used
+const inviteLinkActiveCondition = `(l.revoked_at IS NULL
+	AND (l.expires_at IS NULL OR l.expires_at > CURRENT_TIMESTAMP)
+	AND (l.max_uses IS NULL OR l.use_count < l.max_uses))`
+
+// ValidateInviteLinkRequest checks that the limits of a new invite link can ever be met
+func ValidateInviteLinkRequest(request CreateInviteLinkRequest) error {
+	if request.MaxUses != nil && *request.MaxUses <= 0 {
+		return errors.New("max uses must be positive")
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return errors.New("the expiry time must be in the future")
+	}
+
+	return nil
+}
+
+func generateInviteToken() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
+
+// CreateGroupInviteLink creates an invite link with a new random token and returns its ID and token
+func CreateGroupInviteLink(groupID, createdBy int, request CreateInviteLinkRequest) (int, string, error) {
+	token, err := generateInviteToken()
+	if err != nil {
+		return 0, "", fmt.Errorf("CreateGroupInviteLink: failed to generate token: %v", err)
+	}
+
+	result, err := DB.Exec("INSERT INTO group_invite_links (group_id, token, created_by, max_uses, expires_at) VALUES (?, ?, ?, ?, ?)",
+		groupID, token, createdBy, request.MaxUses, formatTimestamp(request.ExpiresAt))
+	if err != nil {
+		log.Printf("Error inserting group invite link: %v", err)
+		return 0, "", err
+	}
+
+	linkID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("Error retrieving last insert ID: %v", err)
+		return 0, "", err
+	}
+
+	return int(linkID), token, nil
+}
+
+// GetGroupInviteLinks lists the invite links of a group, the newest first
+func GetGroupInviteLinks(groupID int) ([]GroupInviteLink, error) {
+	query := `SELECT l.link_id, l.group_id, l.token, l.created_by, (u.firstname || ' ' || u.lastname), l.max_uses, l.use_count,
+	                 l.expires_at, l.revoked_at, l.created_at, ` + inviteLinkActiveCondition + `
+	          FROM group_invite_links l
+	          JOIN users u ON l.created_by = u.user_id
+	          WHERE l.group_id = ?
+	          ORDER BY l.created_at DESC, l.link_id DESC`
+
+	rows, err := DB.Query(query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("GetGroupInviteLinks: failed to query links: %v", err)
+	}
+	defer rows.Close()
+
+	links := []GroupInviteLink{}
+	for rows.Next() {
+		var link GroupInviteLink
+		err := rows.Scan(&link.LinkID, &link.GroupID, &link.Token, &link.CreatedBy, &link.CreatedByName, &link.MaxUses, &link.UseCount,
+			&link.ExpiresAt, &link.RevokedAt, &link.CreatedAt, &link.Active)
+		if err != nil {
+			return nil, fmt.Errorf("GetGroupInviteLinks: failed to scan link row: %v", err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetGroupInviteLinks: error iterating over link rows: %v", err)
+	}
+
+	return links, nil
+}
+
+// RevokeGroupInviteLink stops an invite link of the group from being used, revoking it again does nothing
+func RevokeGroupInviteLink(groupID, linkID int) error {
+	result, err := DB.Exec("UPDATE group_invite_links SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE link_id = ? AND group_id = ?",
+		linkID, groupID)
+	if err != nil {
+		log.Printf("Error revoking group invite link: %v", err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInviteLinkNotFound
+	}
+
+	return nil
+}
+
+// JoinGroupByInviteLink makes the user a member of the group of the invite link, skipping the
+// join request, and records the use. It returns the ID of the group joined.
+func JoinGroupByInviteLink(token string, userID int) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return 0, err
+	}
+
+	var linkID, groupID int
+	var active bool
+	err = tx.QueryRow("SELECT l.link_id, l.group_id, "+inviteLinkActiveCondition+" FROM group_invite_links l WHERE l.token = ?",
+		token).Scan(&linkID, &groupID, &active)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInviteLinkNotFound
+		}
+		return 0, fmt.Errorf("JoinGroupByInviteLink: failed to fetch link: %v", err)
+	}
+	if !active {
+		tx.Rollback()
+		return 0, ErrInviteLinkInvalid
+	}
+
+	var banned, member bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM group_bans WHERE group_id = ? AND user_id = ?),
+	                          EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ? AND status = 'accepted')`,
+		groupID, userID, groupID, userID).Scan(&banned, &member)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return 0, fmt.Errorf("JoinGroupByInviteLink: failed to check membership: %v", err)
+	}
+	if banned {
+		tx.Rollback()
+		return 0, ErrUserBanned
+	}
+	if member {
+		tx.Rollback()
+		return groupID, ErrAlreadyGroupMember
+	}
+
+	err = acceptGroupMember(tx, groupID, userID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return 0, err
+	}
+
+	// A join request the user had open is answered by joining, like a moderator accepting it
+	_, err = tx.Exec(`UPDATE notifications SET status = 'accepted'
+	                  WHERE type = 'join_group_request' AND reference_id = ? AND second_reference_id = ? AND status = 'unread'`,
+		groupID, userID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error updating join request notifications: %v", err)
+		return 0, err
+	}
+
+	// The limit is checked again, another user may have taken the last use meanwhile
+	result, err := tx.Exec("UPDATE group_invite_links SET use_count = use_count + 1 WHERE link_id = ? AND (max_uses IS NULL OR use_count < max_uses)",
+		linkID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error counting invite link use: %v", err)
+		return 0, err
+	}
+
+	counted, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return 0, err
+	}
+	if counted == 0 {
+		tx.Rollback()
+		return 0, ErrInviteLinkInvalid
+	}
+
+	_, err = tx.Exec("INSERT INTO group_invite_link_uses (link_id, user_id) VALUES (?, ?)", linkID, userID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error inserting invite link use: %v", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return groupID, nil
+}
+
+// GetGroupInviteLinkUses lists who joined the group through its invite links, the latest first
+func GetGroupInviteLinkUses(groupID, limit, offset int) ([]GroupInviteLinkUse, error) {
+	query := `SELECT lu.use_id, lu.link_id, u.user_id, (u.firstname || ' ' || u.lastname), COALESCE(u.avatar, ''), lu.created_at
+	          FROM group_invite_link_uses lu
+	          JOIN group_invite_links l ON lu.link_id = l.link_id
+	          JOIN users u ON lu.user_id = u.user_id
+	          WHERE l.group_id = ?
+	          ORDER BY lu.created_at DESC, lu.use_id DESC
+	          LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, groupID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("GetGroupInviteLinkUses: failed to query uses: %v", err)
+	}
+	defer rows.Close()
+
+	uses := []GroupInviteLinkUse{}
+	for rows.Next() {
+		var use GroupInviteLinkUse
+		err := rows.Scan(&use.UseID, &use.LinkID, &use.UserID, &use.FullName, &use.Avatar, &use.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("GetGroupInviteLinkUses: failed to scan use row: %v", err)
+		}
+		uses = append(uses, use)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetGroupInviteLinkUses: error iterating over use rows: %v", err)
+	}
+
+	return uses, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// GroupMember is an entry of the member directory of a group
+type GroupMember struct {
+	UserID   int    `json:"user_id"`
+	FullName string `json:"full_name"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	Role     string `json:"role"`
+	Status   string `json:"status"`
+	// JoinedAt is only set for accepted members
+	JoinedAt  *time.Time `json:"joined_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type GroupMemberDirectory struct {
+	Members     []GroupMember `json:"members"`
+	MemberCount int           `json:"member_count"`
+	// The counts of join requests and invitations are left out for those who can't review them
+	RequestCount    *int `json:"request_count,omitempty"`
+	InvitationCount *int `json:"invitation_count,omitempty"`
+}
+
+// ValidMemberStatus reports whether the member directory can be filtered by the status
+func ValidMemberStatus(status string) bool {
+	switch status {
+	case "accepted", "request", "invited":
+		return true
+	}
+	return false
+}
+
+// GetGroupMemberDirectory returns one page of the group members with the given status and the
+// count of members, with the counts of join requests and invitations as well if withQueueCounts
+// is set. Accepted members are listed by role and then by join date, requests and invitations the
+// oldest first.
+func GetGroupMemberDirectory(groupID int, status string, withQueueCounts bool, limit, offset int) (GroupMemberDirectory, error) {
+	directory := GroupMemberDirectory{Members: []GroupMember{}}
+
+	var requestCount, invitationCount int
+	err := DB.QueryRow(`SELECT COUNT(CASE WHEN status = 'accepted' THEN 1 END),
+	                           COUNT(CASE WHEN status = 'request' THEN 1 END),
+	                           COUNT(CASE WHEN status = 'invited' THEN 1 END)
+	                    FROM group_members WHERE group_id = ?`, groupID).
+		Scan(&directory.MemberCount, &requestCount, &invitationCount)
+	if err != nil {
+		return GroupMemberDirectory{}, fmt.Errorf("GetGroupMemberDirectory: failed to count members: %v", err)
+	}
+	if withQueueCounts {
+		directory.RequestCount = &requestCount
+		directory.InvitationCount = &invitationCount
+	}
+
+	query := `SELECT u.user_id, (u.firstname || ' ' || u.lastname), COALESCE(u.nickname, ''), COALESCE(u.avatar, ''),
+	                 gm.role, gm.status, gm.joined_at, gm.created_at
+	          FROM group_members gm
+	          JOIN users u ON gm.user_id = u.user_id
+	          WHERE gm.group_id = ? AND gm.status = ?
+	          ORDER BY CASE WHEN gm.status = 'accepted'
+	                        THEN CASE gm.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 WHEN 'moderator' THEN 2 ELSE 3 END END,
+	                   COALESCE(gm.joined_at, gm.created_at), u.user_id
+	          LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, groupID, status, limit, offset)
+	if err != nil {
+		return GroupMemberDirectory{}, fmt.Errorf("GetGroupMemberDirectory: failed to query members: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member GroupMember
+		err := rows.Scan(&member.UserID, &member.FullName, &member.Nickname, &member.Avatar,
+			&member.Role, &member.Status, &member.JoinedAt, &member.CreatedAt)
+		if err != nil {
+			return GroupMemberDirectory{}, fmt.Errorf("GetGroupMemberDirectory: failed to scan member row: %v", err)
+		}
+		if member.Status != "accepted" {
+			member.JoinedAt = nil
+		}
+		directory.Members = append(directory.Members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return GroupMemberDirectory{}, fmt.Errorf("GetGroupMemberDirectory: error iterating over member rows: %v", err)
+	}
+
+	return directory, nil
+}
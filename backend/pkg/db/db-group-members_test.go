@@ -0,0 +1,35 @@
+package db
+
+import "testing"
+
+func TestGetGroupMemberDirectoryQueueCounts(t *testing.T) {
+	owner := newTestUser(t, true)
+	requester := newTestUser(t, true)
+	invited := newTestUser(t, true)
+
+	groupID, _ := newTestGroup(t, owner, GroupPublic)
+	addTestMember(t, groupID, requester, "request", GroupRoleMember)
+	addTestMember(t, groupID, invited, "invited", GroupRoleMember)
+
+	directory, err := GetGroupMemberDirectory(groupID, "accepted", false, 50, 0)
+	if err != nil {
+		t.Fatalf("GetGroupMemberDirectory: %v", err)
+	}
+	if directory.MemberCount != 1 || len(directory.Members) != 1 {
+		t.Errorf("got %d members listed of %d, want 1 of 1", len(directory.Members), directory.MemberCount)
+	}
+	if directory.RequestCount != nil || directory.InvitationCount != nil {
+		t.Errorf("queue counts are returned without withQueueCounts")
+	}
+
+	directory, err = GetGroupMemberDirectory(groupID, "request", true, 50, 0)
+	if err != nil {
+		t.Fatalf("GetGroupMemberDirectory: %v", err)
+	}
+	if directory.RequestCount == nil || *directory.RequestCount != 1 || directory.InvitationCount == nil || *directory.InvitationCount != 1 {
+		t.Errorf("got request count %v and invitation count %v, want 1 and 1", directory.RequestCount, directory.InvitationCount)
+	}
+	if len(directory.Members) != 1 || directory.Members[0].UserID != requester {
+		t.Errorf("request listing = %+v, want only the requester", directory.Members)
+	}
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Approval states of group posts
+const (
+	ApprovalApproved = "approved"
+	ApprovalPending  = "pending"
+	ApprovalRejected = "rejected"
+)
+
+var ErrPostNotPending = errors.New("post is not waiting for approval")
+
+type GroupPostSettingsRequest struct {
+	PostsRequireApproval bool `json:"posts_require_approval"`
+}
+
+// ReviewGroupPostRequest names the pending post to approve or reject
+type ReviewGroupPostRequest struct {
+	PostID int `json:"post_id"`
+}
+
+// SetGroupPostApproval turns the review of new posts on or off. Posts already waiting stay in the queue.
+func SetGroupPostApproval(groupID int, required bool) error {
+	_, err := DB.Exec("UPDATE groups SET posts_require_approval = ? WHERE group_id = ?", required, groupID)
+	if err != nil {
+		log.Printf("Error updating group post approval: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// groupPostNeedsApprovalQuery is bound to the user and the group, see GroupPostNeedsApproval
+const groupPostNeedsApprovalQuery = `SELECT g.posts_require_approval AND NOT EXISTS (SELECT 1 FROM group_members gm
+                                         WHERE gm.group_id = g.group_id AND gm.user_id = ? AND gm.status = 'accepted' AND gm.role != 'member')
+                                     FROM groups g WHERE g.group_id = ?`
+
+// GroupPostNeedsApproval reports whether a new post of the user in the group has to be reviewed first.
+// Posts of moderators and above never do.
+func GroupPostNeedsApproval(groupID, userID int) (bool, error) {
+	var needsApproval bool
+	err := DB.QueryRow(groupPostNeedsApprovalQuery, userID, groupID).Scan(&needsApproval)
+	if err != nil {
+		return false, fmt.Errorf("GroupPostNeedsApproval: failed to check group settings: %v", err)
+	}
+
+	return needsApproval, nil
+}
+
+// GetPendingGroupPosts returns one page of the posts waiting for approval in a group, the oldest first.
+// Drafts only join the queue once their author publishes or schedules them.
+func GetPendingGroupPosts(groupID, viewerID, limit, offset int) ([]Post, error) {
+	query := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.created_at, (u.firstname || ' ' || u.lastname),
+	                 ` + postDetailColumns + `
+	          FROM posts p
+	          JOIN users u ON p.user_id = u.user_id
+	          WHERE p.group_id = ? AND p.approval_status = 'pending' AND p.status != 'draft'
+	          ORDER BY p.created_at, p.post_id
+	          LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, groupID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("GetPendingGroupPosts: failed to query posts: %v", err)
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		var post Post
+		dest := []interface{}{&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.CreatedAt, &post.FullName}
+		err := rows.Scan(append(dest, postDetailDest(&post)...)...)
+		if err != nil {
+			return nil, fmt.Errorf("GetPendingGroupPosts: failed to scan post row: %v", err)
+		}
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetPendingGroupPosts: error iterating over post rows: %v", err)
+	}
+
+	if err := fillPostDetails(posts, viewerID); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// ReviewGroupPost approves or rejects a pending post of the group and notifies its author. It
+// returns whether the post is published, scheduled posts only show up once their time has come.
+func ReviewGroupPost(groupID, postID, reviewerID int, approved bool) (bool, error) {
+	status := ApprovalRejected
+	if approved {
+		status = ApprovalApproved
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return false, err
+	}
+
+	result, err := tx.Exec(`UPDATE posts SET approval_status = ?, reviewed_by = ?, reviewed_at = CURRENT_TIMESTAMP
+	                        WHERE post_id = ? AND group_id = ? AND approval_status = 'pending' AND status != 'draft'`,
+		status, reviewerID, postID, groupID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error reviewing group post: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return false, err
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return false, ErrPostNotPending
+	}
+
+	err = createGroupPostReviewNotification(tx, groupID, postID, approved)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return false, err
+	}
+
+	var published bool
+	err = tx.QueryRow("SELECT status = 'published' FROM posts WHERE post_id = ?", postID).Scan(&published)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return false, fmt.Errorf("ReviewGroupPost: failed to fetch post status: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return published, nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// PendingGroupMember is a user who asked to join a group or was invited to it
+type PendingGroupMember struct {
+	UserID    int       `json:"user_id"`
+	FullName  string    `json:"full_name"`
+	Avatar    string    `json:"avatar"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PendingGroupMembers struct {
+	Requests    []PendingGroupMember `json:"requests"`
+	Invitations []PendingGroupMember `json:"invitations"`
+}
+
+type ReviewJoinRequestsRequest struct {
+	UserIDs  []int `json:"user_ids"`
+	Accepted bool  `json:"accepted"`
+}
+
+// GetPendingGroupMembers lists the open join requests and invitations of a group, the oldest first
+func GetPendingGroupMembers(groupID int) (PendingGroupMembers, error) {
+	pending := PendingGroupMembers{Requests: []PendingGroupMember{}, Invitations: []PendingGroupMember{}}
+
+	query := `SELECT u.user_id, (u.firstname || ' ' || u.lastname), COALESCE(u.avatar, ''), gm.status, gm.created_at
+	          FROM group_members gm
+	          JOIN users u ON gm.user_id = u.user_id
+	          WHERE gm.group_id = ? AND gm.status IN ('request', 'invited')
+	          ORDER BY gm.created_at, u.user_id`
+
+	rows, err := DB.Query(query, groupID)
+	if err != nil {
+		return PendingGroupMembers{}, fmt.Errorf("GetPendingGroupMembers: failed to query members: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member PendingGroupMember
+		if err := rows.Scan(&member.UserID, &member.FullName, &member.Avatar, &member.Status, &member.CreatedAt); err != nil {
+			return PendingGroupMembers{}, fmt.Errorf("GetPendingGroupMembers: failed to scan member row: %v", err)
+		}
+
+		if member.Status == "request" {
+			pending.Requests = append(pending.Requests, member)
+		} else {
+			pending.Invitations = append(pending.Invitations, member)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return PendingGroupMembers{}, fmt.Errorf("GetPendingGroupMembers: error iterating over member rows: %v", err)
+	}
+
+	return pending, nil
+}
+
+// ReviewJoinRequests accepts or rejects the join requests of the given users in one transaction.
+// Users without an open request are skipped, it returns the users whose request was answered.
+func ReviewJoinRequests(groupID int, userIDs []int, accepted bool) ([]int, error) {
+	status := "rejected"
+	if accepted {
+		status = "accepted"
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+
+	reviewed := []int{}
+	seen := make(map[int]bool)
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		var pending bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ? AND status = 'request')",
+			groupID, userID).Scan(&pending)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return nil, fmt.Errorf("ReviewJoinRequests: failed to check join request: %v", err)
+		}
+		if !pending {
+			continue
+		}
+
+		// handleJoinGroup rolls back the transaction itself when updating the request fails
+		err = handleJoinGroup(tx, groupID, sql.NullInt64{Int64: int64(userID), Valid: true}, status)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return nil, err
+		}
+		reviewed = append(reviewed, userID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return reviewed, nil
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Roles of accepted group members, from the most to the least privileged
+const (
+	GroupRoleOwner     = "owner"
+	GroupRoleAdmin     = "admin"
+	GroupRoleModerator = "moderator"
+	GroupRoleMember    = "member"
+)
+
+var (
+	ErrNotGroupMember  = errors.New("user is not a member of the group")
+	ErrInvalidRole     = errors.New("invalid group role")
+	ErrLastGroupOwner  = errors.New("the owner can't leave a group without other members")
+	ErrGroupPermission = errors.New("your group role doesn't allow this")
+)
+
+// GroupRoleRequest names a member and, when changing roles, their new role
+type GroupRoleRequest struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role,omitempty"`
+}
+
+var groupRoleRanks = map[string]int{
+	GroupRoleMember:    1,
+	GroupRoleModerator: 2,
+	GroupRoleAdmin:     3,
+	GroupRoleOwner:     4,
+}
+
+// ValidAssignableRole reports whether a role can be given with SetGroupMemberRole,
+// ownership is only handed over with TransferGroupOwnership
+func ValidAssignableRole(role string) bool {
+	switch role {
+	case GroupRoleAdmin, GroupRoleModerator, GroupRoleMember:
+		return true
+	}
+	return false
+}
+
+// GroupRoleOutranks reports whether role is strictly more privileged than other
+func GroupRoleOutranks(role, other string) bool {
+	return groupRoleRanks[role] > groupRoleRanks[other]
+}
+
+// GetGroupRole returns the role of an accepted member of the group, or an empty string if
+// the user is not an accepted member
+func GetGroupRole(groupID, userID int) (string, error) {
+	var role string
+	err := DB.QueryRow("SELECT role FROM group_members WHERE group_id = ? AND user_id = ? AND status = 'accepted'", groupID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("GetGroupRole: failed to fetch role: %v", err)
+	}
+
+	return role, nil
+}
+
+// HasGroupRole reports whether the user is an accepted member of the group with at least the given role
+func HasGroupRole(groupID, userID int, minRole string) (bool, error) {
+	role, err := GetGroupRole(groupID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return role != "" && groupRoleRanks[role] >= groupRoleRanks[minRole], nil
+}
+
+// SetGroupMemberRole changes the role of an accepted member, the caller checks that the acting
+// user outranks both the member's current and new role
+func SetGroupMemberRole(groupID, userID int, role string) error {
+	result, err := DB.Exec("UPDATE group_members SET role = ? WHERE group_id = ? AND user_id = ? AND status = 'accepted' AND role != 'owner'",
+		role, groupID, userID)
+	if err != nil {
+		log.Printf("Error updating group member role: %v", err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotGroupMember
+	}
+
+	return nil
+}
+
+// TransferGroupOwnership makes an accepted member the owner of the group, the previous owner becomes an admin
+func TransferGroupOwnership(groupID, newOwnerID int) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	err = transferGroupOwnership(tx, groupID, newOwnerID, GroupRoleAdmin)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// transferGroupOwnership hands the group over to an accepted member, giving the previous owner the given role
+func transferGroupOwnership(tx *sql.Tx, groupID, newOwnerID int, previousOwnerRole string) error {
+	var isMember bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ? AND status = 'accepted')",
+		groupID, newOwnerID).Scan(&isMember)
+	if err != nil {
+		return fmt.Errorf("transferGroupOwnership: failed to check membership: %v", err)
+	}
+	if !isMember {
+		return ErrNotGroupMember
+	}
+
+	_, err = tx.Exec("UPDATE group_members SET role = ? WHERE group_id = ? AND role = 'owner'", previousOwnerRole, groupID)
+	if err != nil {
+		log.Printf("Error demoting previous group owner: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE group_members SET role = 'owner' WHERE group_id = ? AND user_id = ?", groupID, newOwnerID)
+	if err != nil {
+		log.Printf("Error updating group owner: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE groups SET user_id = ? WHERE group_id = ?", newOwnerID, groupID)
+	if err != nil {
+		log.Printf("Error updating group creator: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// groupSuccessor picks who takes over a group when the owner leaves: the most privileged
+// remaining member, the longest standing one among equals. It returns 0 if nobody is left.
+func groupSuccessor(tx *sql.Tx, groupID, ownerID int) (int, error) {
+	var successorID int
+	err := tx.QueryRow(`SELECT user_id FROM group_members
+	                    WHERE group_id = ? AND user_id != ? AND status = 'accepted'
+	                    ORDER BY CASE role WHEN 'admin' THEN 1 WHEN 'moderator' THEN 2 ELSE 3 END, created_at, user_id
+	                    LIMIT 1`, groupID, ownerID).Scan(&successorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("groupSuccessor: failed to fetch successor: %v", err)
+	}
+
+	return successorID, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import "testing"
+
+func TestHasGroupRole(t *testing.T) {
+	owner := newTestUser(t, true)
+	admin := newTestUser(t, true)
+	moderator := newTestUser(t, true)
+	member := newTestUser(t, true)
+	invited := newTestUser(t, true)
+	outsider := newTestUser(t, true)
+
+	groupID, _ := newTestGroup(t, owner, GroupClosed)
+	addTestMember(t, groupID, admin, "accepted", GroupRoleAdmin)
+	addTestMember(t, groupID, moderator, "accepted", GroupRoleModerator)
+	addTestMember(t, groupID, member, "accepted", GroupRoleMember)
+	addTestMember(t, groupID, invited, "invited", GroupRoleMember)
+
+	roles := []string{GroupRoleMember, GroupRoleModerator, GroupRoleAdmin, GroupRoleOwner}
+	tests := []struct {
+		name   string
+		userID int
+		// rank is the number of roles of the list above the user holds, 0 for none
+		rank int
+	}{
+		{"owner", owner, 4},
+		{"admin", admin, 3},
+		{"moderator", moderator, 2},
+		{"member", member, 1},
+		{"invited", invited, 0},
+		{"outsider", outsider, 0},
+	}
+
+	for _, tt := range tests {
+		for i, minRole := range roles {
+			got, err := HasGroupRole(groupID, tt.userID, minRole)
+			if err != nil {
+				t.Fatalf("%s, %s: %v", tt.name, minRole, err)
+			}
+			if want := i < tt.rank; got != want {
+				t.Errorf("%s: HasGroupRole(%s) = %v, want %v", tt.name, minRole, got, want)
+			}
+		}
+	}
+}
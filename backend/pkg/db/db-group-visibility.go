@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Visibility levels of a group
+const (
+	GroupPublic = "public"
+	GroupClosed = "closed"
+	GroupSecret = "secret"
+)
+
+var ErrGroupNotFound = errors.New("group not found")
+
+// groupListedCondition matches the groups (aliased g) the viewer may know about: all but the
+// secret ones, which only their members and invited users see. It expects the viewer's user ID bound once.
+const groupListedCondition = `(g.visibility != 'secret'
+	OR EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = g.group_id AND gm.user_id = ? AND gm.status IN ('accepted', 'invited')))`
+
+// groupReadableCondition matches the groups (aliased g) whose posts and events the viewer may read,
+// see CanReadGroupContent. It expects the viewer's user ID bound once.
+const groupReadableCondition = `(g.visibility = 'public'
+	OR EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = g.group_id AND gm.user_id = ? AND gm.status = 'accepted'))`
+
+func ValidGroupVisibility(visibility string) bool {
+	switch visibility {
+	case GroupPublic, GroupClosed, GroupSecret:
+		return true
+	}
+	return false
+}
+
+// GetGroupVisibility returns the visibility of a group, or ErrGroupNotFound if there is no such group
+func GetGroupVisibility(groupID int) (string, error) {
+	var visibility string
+	err := DB.QueryRow("SELECT visibility FROM groups WHERE group_id = ?", groupID).Scan(&visibility)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrGroupNotFound
+		}
+		return "", fmt.Errorf("GetGroupVisibility: failed to fetch group: %v", err)
+	}
+
+	return visibility, nil
+}
+
+// CanSeeGroup reports whether the group exists and the viewer may know about it
+func CanSeeGroup(groupID, viewerID int) (bool, error) {
+	var visible bool
+	err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM groups g WHERE g.group_id = ? AND "+groupListedCondition+")",
+		groupID, viewerID).Scan(&visible)
+	if err != nil {
+		return false, fmt.Errorf("CanSeeGroup: failed to check group visibility: %v", err)
+	}
+
+	return visible, nil
+}
+
+// CanReadGroupContent reports whether the viewer may read the posts, events and chat of a group:
+// anyone for public groups, accepted members otherwise
+func CanReadGroupContent(groupID, viewerID int) (bool, error) {
+	var readable bool
+	err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM groups g WHERE g.group_id = ? AND "+groupReadableCondition+")",
+		groupID, viewerID).Scan(&readable)
+	if err != nil {
+		return false, fmt.Errorf("CanReadGroupContent: failed to check group access: %v", err)
+	}
+
+	return readable, nil
+}
+
+// CanAccessChat reports whether the user may read and write in a chat. Private chats are for
+// their participants, group chats for the accepted members of the group only.
+func CanAccessChat(chatID, userID int) (bool, error) {
+	var groupID sql.NullInt64
+	err := DB.QueryRow("SELECT group_id FROM chats WHERE chat_id = ?", chatID).Scan(&groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("CanAccessChat: failed to fetch chat: %v", err)
+	}
+
+	if !groupID.Valid {
+		var participant bool
+		err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM chat_participants WHERE chat_id = ? AND participant_id = ?)",
+			chatID, userID).Scan(&participant)
+		if err != nil {
+			return false, fmt.Errorf("CanAccessChat: failed to check chat participants: %v", err)
+		}
+		return participant, nil
+	}
+
+	return HasGroupRole(int(groupID.Int64), userID, GroupRoleMember)
+}
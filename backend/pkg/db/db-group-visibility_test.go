@@ -0,0 +1,125 @@
+package db
+
+import "testing"
+
+func TestCanSeeGroup(t *testing.T) {
+	owner := newTestUser(t, true)
+	member := newTestUser(t, true)
+	invited := newTestUser(t, true)
+	requester := newTestUser(t, true)
+	outsider := newTestUser(t, true)
+
+	for _, visibility := range []string{GroupPublic, GroupClosed, GroupSecret} {
+		groupID, _ := newTestGroup(t, owner, visibility)
+		addTestMember(t, groupID, member, "accepted", GroupRoleMember)
+		addTestMember(t, groupID, invited, "invited", GroupRoleMember)
+		addTestMember(t, groupID, requester, "request", GroupRoleMember)
+
+		secret := visibility == GroupSecret
+		tests := []struct {
+			name   string
+			userID int
+			want   bool
+		}{
+			{"member", member, true},
+			{"invited", invited, true},
+			{"requester", requester, !secret},
+			{"outsider", outsider, !secret},
+		}
+
+		for _, tt := range tests {
+			got, err := CanSeeGroup(groupID, tt.userID)
+			if err != nil {
+				t.Fatalf("%s group, %s: %v", visibility, tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s group, %s: CanSeeGroup = %v, want %v", visibility, tt.name, got, tt.want)
+			}
+		}
+	}
+
+	if visible, err := CanSeeGroup(-1, owner); err != nil || visible {
+		t.Errorf("missing group: CanSeeGroup = %v, %v, want false", visible, err)
+	}
+}
+
+func TestCanReadGroupContent(t *testing.T) {
+	owner := newTestUser(t, true)
+	member := newTestUser(t, true)
+	invited := newTestUser(t, true)
+	outsider := newTestUser(t, true)
+
+	for _, visibility := range []string{GroupPublic, GroupClosed, GroupSecret} {
+		groupID, _ := newTestGroup(t, owner, visibility)
+		addTestMember(t, groupID, member, "accepted", GroupRoleMember)
+		addTestMember(t, groupID, invited, "invited", GroupRoleMember)
+
+		public := visibility == GroupPublic
+		tests := []struct {
+			name   string
+			userID int
+			want   bool
+		}{
+			{"member", member, true},
+			{"invited", invited, public},
+			{"outsider", outsider, public},
+		}
+
+		for _, tt := range tests {
+			got, err := CanReadGroupContent(groupID, tt.userID)
+			if err != nil {
+				t.Fatalf("%s group, %s: %v", visibility, tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s group, %s: CanReadGroupContent = %v, want %v", visibility, tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestCanAccessChat(t *testing.T) {
+	owner := newTestUser(t, true)
+	member := newTestUser(t, true)
+	requester := newTestUser(t, true)
+	outsider := newTestUser(t, true)
+
+	// Even public groups keep their chat to members
+	groupID, groupChatID := newTestGroup(t, owner, GroupPublic)
+	addTestMember(t, groupID, member, "accepted", GroupRoleMember)
+	addTestMember(t, groupID, requester, "request", GroupRoleMember)
+
+	result, err := DB.Exec("INSERT INTO chats (group_id) VALUES (NULL)")
+	if err != nil {
+		t.Fatalf("inserting private chat: %v", err)
+	}
+	privateChatID := lastInsertID(t, result.LastInsertId)
+	for _, participant := range []int{owner, member} {
+		if _, err := DB.Exec("INSERT INTO chat_participants (chat_id, participant_id) VALUES (?, ?)", privateChatID, participant); err != nil {
+			t.Fatalf("inserting chat participant: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		chatID int
+		userID int
+		want   bool
+	}{
+		{"group chat, member", groupChatID, member, true},
+		{"group chat, requester", groupChatID, requester, false},
+		{"group chat, outsider", groupChatID, outsider, false},
+		{"private chat, participant", privateChatID, member, true},
+		{"private chat, outsider", privateChatID, outsider, false},
+		{"missing chat", -1, owner, false},
+	}
+
+	for _, tt := range tests {
+		got, err := CanAccessChat(tt.chatID, tt.userID)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: CanAccessChat = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
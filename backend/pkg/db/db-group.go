@@ -18,15 +18,27 @@ type Group struct {
 	CreatorLastname  string
 	Members          []int     `json:"members"`
 	CreatedAt        time.Time `json:"created_at,omitempty"`
+	// Visibility is public, closed or secret, see the Group* constants
+	Visibility string  `json:"visibility"`
+	CoverImage *string `json:"cover_image,omitempty"`
+	// PostsRequireApproval holds the posts of members for review by a moderator
+	PostsRequireApproval bool `json:"posts_require_approval"`
 }
 
 type GroupPost struct {
-	PostID    int       `json:"post_id,omitempty"`
-	UserID    int       `json:"user_id"`
-	GroupID   int       `json:"group_id,omitempty"`
-	Content   string    `json:"content"`
-	PostImage *string   `json:"post_image,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
+	PostID        int        `json:"post_id,omitempty"`
+	UserID        int        `json:"user_id"`
+	GroupID       int        `json:"group_id,omitempty"`
+	Content       string     `json:"content"`
+	PostImage     *string    `json:"post_image,omitempty"`
+	CommentPolicy string     `json:"comment_policy"`
+	Poll          *Poll      `json:"poll,omitempty"`
+	Media         []Media    `json:"media,omitempty"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at,omitempty"`
+	// ApprovalStatus is set by the server, see GroupPostNeedsApproval
+	ApprovalStatus string `json:"-"`
 }
 
 type InviteRequest struct {
@@ -34,14 +46,18 @@ type InviteRequest struct {
 }
 
 func InsertGroup(group Group) (groupID int, err error) {
-	statement, err := DB.Prepare(`INSERT INTO groups (user_id, title, content) VALUES (?, ?, ?)`)
+	if group.Visibility == "" {
+		group.Visibility = GroupClosed
+	}
+
+	statement, err := DB.Prepare(`INSERT INTO groups (user_id, title, content, visibility) VALUES (?, ?, ?, ?)`)
 	if err != nil {
 		log.Printf("Error preparing insert statement for group: %v", err)
 		return 0, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(group.UserID, group.Title, group.Content)
+	result, err := statement.Exec(group.UserID, group.Title, group.Content, group.Visibility)
 	if err != nil {
 		log.Printf("Error executing insert statement for group: %v", err)
 		return 0, err
@@ -53,6 +69,13 @@ func InsertGroup(group Group) (groupID int, err error) {
 		return 0, err
 	}
 
+	// The creator owns the group
+	_, err = DB.Exec("INSERT INTO group_members (group_id, user_id, status, role) VALUES (?, ?, 'accepted', 'owner')", GroupID, group.UserID)
+	if err != nil {
+		log.Printf("Error inserting group owner: %v", err)
+		return 0, err
+	}
+
 	var chatID int64
 	chatResult, err := DB.Exec("INSERT INTO chats (group_id) VALUES (?)", GroupID)
 	if err != nil {
@@ -76,60 +99,234 @@ func InsertGroup(group Group) (groupID int, err error) {
 	return int(GroupID), nil
 }
 
-func InsertGroupMember(groupID, memberID int, status string) error {
-	// Prepare the SQL statement to insert a new group member with status
-	stmt, err := DB.Prepare("INSERT INTO group_members (group_id, user_id, status) VALUES (?, ?, ?)")
+// Reasons for not inviting a user to a group
+const (
+	InviteSkippedMember    = "member"
+	InviteSkippedInvited   = "invited"
+	InviteSkippedRequested = "requested"
+	InviteSkippedBanned    = "banned"
+	InviteSkippedNotFound  = "not_found"
+)
+
+// MaxGroupInviteBatch is the most users that can be invited at once
+const MaxGroupInviteBatch = 100
+
+type SkippedInvite struct {
+	UserID int    `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type GroupInviteResult struct {
+	Invited []int           `json:"invited"`
+	Skipped []SkippedInvite `json:"skipped"`
+}
+
+// InviteUsersToGroup invites the users and notifies them in one transaction. Members, users
+// already invited or asking to join, banned and unknown users are skipped, so inviting
+// someone twice doesn't send a second notification. Users who left or were turned down are invited again.
+func InviteUsersToGroup(groupID, inviterID int, userIDs []int) (GroupInviteResult, error) {
+	result := GroupInviteResult{Invited: []int{}, Skipped: []SkippedInvite{}}
+
+	tx, err := DB.Begin()
 	if err != nil {
-		return err
+		log.Printf("Error starting transaction: %v", err)
+		return GroupInviteResult{}, err
 	}
-	defer stmt.Close()
 
-	// Execute the SQL statement to insert the group member
-	_, err = stmt.Exec(groupID, memberID, status)
-	if err != nil {
-		return err
+	seen := make(map[int]bool)
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		var exists, banned bool
+		var status sql.NullString
+		err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE user_id = ?),
+		                           EXISTS(SELECT 1 FROM group_bans WHERE group_id = ? AND user_id = ?),
+		                           (SELECT status FROM group_members WHERE group_id = ? AND user_id = ?)`,
+			userID, groupID, userID, groupID, userID).Scan(&exists, &banned, &status)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return GroupInviteResult{}, fmt.Errorf("InviteUsersToGroup: failed to check user %d: %v", userID, err)
+		}
+
+		reason := ""
+		switch {
+		case !exists:
+			reason = InviteSkippedNotFound
+		case banned:
+			reason = InviteSkippedBanned
+		case status.String == "accepted":
+			reason = InviteSkippedMember
+		case status.String == "invited":
+			reason = InviteSkippedInvited
+		case status.String == "request":
+			reason = InviteSkippedRequested
+		}
+		if reason != "" {
+			result.Skipped = append(result.Skipped, SkippedInvite{UserID: userID, Reason: reason})
+			continue
+		}
+
+		_, err = tx.Exec(`INSERT INTO group_members (group_id, user_id, status) VALUES (?, ?, 'invited')
+		                  ON CONFLICT (group_id, user_id) DO UPDATE SET status = 'invited', role = 'member'`, groupID, userID)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			log.Printf("Error inserting group invitation: %v", err)
+			return GroupInviteResult{}, err
+		}
+
+		err = createGroupInvitationNotification(tx, inviterID, userID, groupID)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return GroupInviteResult{}, err
+		}
+
+		result.Invited = append(result.Invited, userID)
 	}
 
-	return nil
+	if err := tx.Commit(); err != nil {
+		return GroupInviteResult{}, err
+	}
+
+	return result, nil
 }
 
-func JoinGroup(userID, groupID int) error {
+// JoinGroup lets the user into a public group right away and asks to join a closed group.
+// Secret groups can only be joined by users invited to them. It returns true if the user is now a member.
+func JoinGroup(userID, groupID int) (bool, error) {
+	banned, err := IsBannedFromGroup(groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	if banned {
+		return false, ErrUserBanned
+	}
+
+	visibility, err := GetGroupVisibility(groupID)
+	if err != nil {
+		return false, err
+	}
+
 	var status string
 
-	err := DB.QueryRow("SELECT status FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID).Scan(&status)
-	switch {
-	case err == sql.ErrNoRows:
+	err = DB.QueryRow("SELECT status FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID).Scan(&status)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error querying group_members table: %v", err)
+		return false, err
+	}
+
+	if status == "accepted" {
+		return true, nil
+	}
+
+	if visibility == GroupPublic || (visibility == GroupSecret && status == "invited") {
+		return true, addGroupMember(groupID, userID)
+	}
+
+	// Secret groups don't give away that they exist
+	if visibility == GroupSecret {
+		return false, ErrGroupNotFound
+	}
+
+	switch status {
+	case "":
 		_, err := DB.Exec("INSERT INTO group_members (group_id, user_id, status) VALUES (?, ?, ?)", groupID, userID, "request")
 		if err != nil {
 			log.Printf("Error inserting join group request into database: %v", err)
-			return err
+			return false, err
 		}
 
-	case err != nil:
-		log.Printf("Error querying group_members table: %v", err)
-		return err
+	case "request":
 
 	default:
-		if status != "request" {
-			_, err := DB.Exec("UPDATE group_members SET status = 'request' WHERE group_id = ? AND user_id = ?", groupID, userID)
-			if err != nil {
-				log.Printf("Error updating join group request status in database: %v", err)
-				return err
-			}
+		_, err := DB.Exec("UPDATE group_members SET status = 'request' WHERE group_id = ? AND user_id = ?", groupID, userID)
+		if err != nil {
+			log.Printf("Error updating join group request status in database: %v", err)
+			return false, err
 		}
 	}
 
-	return nil
+	return false, nil
+}
+
+// addGroupMember makes the user an accepted member of the group and adds them to the group chat
+func addGroupMember(groupID, userID int) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	err = acceptGroupMember(tx, groupID, userID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// acceptGroupMember turns any request or invitation of the user into an accepted membership
+// within the transaction and adds them to the group chat
+func acceptGroupMember(tx *sql.Tx, groupID, userID int) error {
+	_, err := tx.Exec(`INSERT INTO group_members (group_id, user_id, status) VALUES (?, ?, 'accepted')
+	                   ON CONFLICT (group_id, user_id) DO UPDATE SET status = 'accepted', role = 'member'`, groupID, userID)
+	if err != nil {
+		log.Printf("Error inserting group member: %v", err)
+		return err
+	}
+
+	return addUserToGroupChat(tx, groupID, userID)
 }
 
+// LeaveGroup ends the user's membership. When the owner leaves, the group is handed over to
+// the next member in line, the owner of a group without other members can't leave it.
 func LeaveGroup(userID, groupID int) error {
-	// Update status in the group_members table to 'rejected'
-	_, err := DB.Exec("UPDATE group_members SET status = 'rejected' WHERE group_id = ? AND user_id = ?", groupID, userID)
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	var role string
+	err = tx.QueryRow("SELECT role FROM group_members WHERE group_id = ? AND user_id = ? AND status = 'accepted'", groupID, userID).Scan(&role)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback() // Roll back in case of error
+		return fmt.Errorf("LeaveGroup: failed to fetch role: %v", err)
+	}
+
+	if role == GroupRoleOwner {
+		successorID, err := groupSuccessor(tx, groupID, userID)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return err
+		}
+		if successorID == 0 {
+			tx.Rollback()
+			return ErrLastGroupOwner
+		}
+
+		err = transferGroupOwnership(tx, groupID, successorID, GroupRoleMember)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return err
+		}
+	}
+
+	// Update status in the group_members table to 'rejected', members who come back start without a role
+	_, err = tx.Exec("UPDATE group_members SET status = 'rejected', role = 'member' WHERE group_id = ? AND user_id = ?", groupID, userID)
 	if err != nil {
+		tx.Rollback() // Roll back in case of error
 		log.Printf("Error updating group membership status: %v", err)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	// leave the group chat
 	err = removeUserFromGroupChat(groupID, userID)
 	if err != nil {
@@ -143,7 +340,7 @@ func LeaveGroup(userID, groupID int) error {
 // GetMyGroups fetches all groups that the user is a member of
 func GetMyGroups(userID int) ([]Group, error) {
 	query := `
-        SELECT g.group_id, g.user_id, g.title, g.content, u.firstname, u.lastname, g.created_at
+        SELECT g.group_id, g.user_id, g.title, g.content, u.firstname, u.lastname, g.created_at, g.visibility, g.cover_image
         FROM groups g
         INNER JOIN users u ON g.user_id = u.user_id
         INNER JOIN group_members gm ON g.group_id = gm.group_id
@@ -158,7 +355,7 @@ func GetMyGroups(userID int) ([]Group, error) {
 	var groups []Group
 	for rows.Next() {
 		var group Group
-		err := rows.Scan(&group.GroupID, &group.UserID, &group.Title, &group.Content, &group.CreatorFirstname, &group.CreatorLastname, &group.CreatedAt)
+		err := rows.Scan(&group.GroupID, &group.UserID, &group.Title, &group.Content, &group.CreatorFirstname, &group.CreatorLastname, &group.CreatedAt, &group.Visibility, &group.CoverImage)
 		if err != nil {
 			return nil, fmt.Errorf("GetMyGroups: failed to scan group row: %v", err)
 		}
@@ -173,13 +370,15 @@ func GetMyGroups(userID int) ([]Group, error) {
 	return groups, nil
 }
 
-func GetAllGroups() ([]Group, error) {
+// GetAllGroups lists the groups the viewer may know about, secret groups only show up for their members
+func GetAllGroups(viewerID int) ([]Group, error) {
 	query := `
-        SELECT g.group_id, g.user_id, g.title, g.content, u.firstname, u.lastname, g.created_at
+        SELECT g.group_id, g.user_id, g.title, g.content, u.firstname, u.lastname, g.created_at, g.visibility, g.cover_image
         FROM groups g
-        INNER JOIN users u ON g.user_id = u.user_id`
+        INNER JOIN users u ON g.user_id = u.user_id
+        WHERE ` + groupListedCondition
 
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("GetAllGroups: failed to query groups: %v", err)
 	}
@@ -188,7 +387,7 @@ func GetAllGroups() ([]Group, error) {
 	var groups []Group
 	for rows.Next() {
 		var group Group
-		err := rows.Scan(&group.GroupID, &group.UserID, &group.Title, &group.Content, &group.CreatorFirstname, &group.CreatorLastname, &group.CreatedAt)
+		err := rows.Scan(&group.GroupID, &group.UserID, &group.Title, &group.Content, &group.CreatorFirstname, &group.CreatorLastname, &group.CreatedAt, &group.Visibility, &group.CoverImage)
 		if err != nil {
 			return nil, fmt.Errorf("GetAllGroups: failed to scan group row: %v", err)
 		}
@@ -207,11 +406,11 @@ func GetAllGroups() ([]Group, error) {
 func GetGroupByID(groupID int) (Group, error) {
 	query := `
 		SELECT g.group_id, g.user_id, g.title, g.content, u.firstname, u.lastname, g.created_at, g.user_id,
-		m.user_id
+		m.user_id, g.visibility, g.cover_image, g.posts_require_approval
 		FROM groups g
 		INNER JOIN users u ON g.user_id = u.user_id
-		LEFT JOIN group_members m ON g.group_id = m.group_id
-		WHERE g.group_id = ? AND m.status IN ('accepted', 'request', 'invited')`
+		LEFT JOIN group_members m ON g.group_id = m.group_id AND m.status = 'accepted'
+		WHERE g.group_id = ?`
 
 	var group Group
 	rows, err := DB.Query(query, groupID)
@@ -227,16 +426,18 @@ func GetGroupByID(groupID int) (Group, error) {
 	memberIDsMap := make(map[int]bool)
 
 	for rows.Next() {
-		var memberID int
+		var memberID sql.NullInt64
 		err := rows.Scan(&group.GroupID, &group.UserID, &group.Title, &group.Content, &group.CreatorFirstname,
-			&group.CreatorLastname, &group.CreatedAt, &group.UserID, &memberID)
+			&group.CreatorLastname, &group.CreatedAt, &group.UserID, &memberID, &group.Visibility, &group.CoverImage, &group.PostsRequireApproval)
 		if err != nil {
 			return Group{}, fmt.Errorf("GetGroupByID: failed to scan row: %v", err)
 		}
 		group.CreatorName = fmt.Sprintf("%s %s", group.CreatorFirstname, group.CreatorLastname)
 
 		// Add member ID to the map if not already present
-		memberIDsMap[memberID] = true
+		if memberID.Valid {
+			memberIDsMap[int(memberID.Int64)] = true
+		}
 	}
 
 	// Convert map keys to a slice of member IDs
@@ -268,45 +469,85 @@ func CheckMembership(userID, groupID int) (string, error) {
 	return status, nil
 }
 
-func InsertGroupPost(groupPost GroupPost) error {
+// IsGroupAdmin reports whether the user administrates the group, as its owner or one of its admins
+func IsGroupAdmin(groupID, userID int) (bool, error) {
+	return HasGroupRole(groupID, userID, GroupRoleAdmin)
+}
+
+func InsertGroupPost(groupPost GroupPost) (int, error) {
+	if groupPost.CommentPolicy == "" {
+		groupPost.CommentPolicy = CommentPolicyEveryone
+	}
+	if groupPost.Status == "" {
+		groupPost.Status = PostPublished
+	}
+	if groupPost.ApprovalStatus == "" {
+		groupPost.ApprovalStatus = ApprovalApproved
+	}
+
 	statement, err := DB.Prepare(`
-        INSERT INTO posts (user_id, group_id, content, post_image)
-        VALUES (?, ?, ?, ?)
+        INSERT INTO posts (user_id, group_id, content, post_image, comment_policy, status, publish_at, approval_status)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
     `)
 	if err != nil {
 		log.Printf("Error preparing insert statement: %v", err)
-		return err
+		return 0, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(groupPost.UserID, groupPost.GroupID, groupPost.Content, groupPost.PostImage)
+	result, err := statement.Exec(groupPost.UserID, groupPost.GroupID, groupPost.Content, groupPost.PostImage, groupPost.CommentPolicy,
+		groupPost.Status, formatTimestamp(groupPost.PublishAt), groupPost.ApprovalStatus)
 	if err != nil {
 		log.Printf("Error executing insert statement: %v", err)
-		return err
+		return 0, err
 	}
 
 	postID, err := result.LastInsertId()
 	if err != nil {
 		log.Printf("Error retrieving last insert ID: %v", err)
-		return err
+		return 0, err
 	}
 
 	fmt.Println("Group Post inserted to DB successfully! postID: ", postID)
 
-	return nil
+	err = insertPostMedia(int(postID), groupPost.Media)
+	if err != nil {
+		return 0, err
+	}
+
+	if groupPost.Poll != nil {
+		err = insertPoll(int(postID), *groupPost.Poll)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = indexPostTags(int(postID), groupPost.Content)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(postID), nil
 }
 
 func handleGroupInvitation(tx *sql.Tx, groupID, userID int, status string) error {
 
-	_, err := tx.Exec("UPDATE group_members SET status = ? WHERE group_id = ? AND user_id = ?", status, groupID, userID)
+	// Only open invitations can be answered, members removed or banned meanwhile stay out
+	result, err := tx.Exec("UPDATE group_members SET status = ? WHERE group_id = ? AND user_id = ? AND status = 'invited'", status, groupID, userID)
 	if err != nil {
 		tx.Rollback() // Roll back in case of error
 		log.Printf("Failed to update group membership status: %v", err)
 		return err
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return err
+	}
+
 	// If the group invitation is accepted, add chat participant
-	if status == "accepted" {
+	if status == "accepted" && affected > 0 {
 		return addUserToGroupChat(tx, groupID, userID)
 	}
 
@@ -315,14 +556,40 @@ func handleGroupInvitation(tx *sql.Tx, groupID, userID int, status string) error
 
 func handleJoinGroup(tx *sql.Tx, groupID int, secondReferenceID sql.NullInt64, status string) error {
 
-	// Update the group_members table to reflect the decision for the user who requested to join
-	_, err := tx.Exec("UPDATE group_members SET status = ? WHERE group_id = ? AND user_id = ?", status, groupID, secondReferenceID.Int64)
+	// Update the group_members table to reflect the decision for the user who requested to join,
+	// requests that were already answered are left alone
+	result, err := tx.Exec("UPDATE group_members SET status = ? WHERE group_id = ? AND user_id = ? AND status = 'request'", status, groupID, secondReferenceID.Int64)
 	if err != nil {
 		tx.Rollback() // Roll back in case of error
 		log.Printf("Failed to update join group request status: %v", err)
 		return err
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return err
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	// Every moderator got the request, so their notifications are answered as well
+	_, err = tx.Exec(`UPDATE notifications SET status = ?
+	                  WHERE type = 'join_group_request' AND reference_id = ? AND second_reference_id = ? AND status = 'unread'`,
+		status, groupID, secondReferenceID.Int64)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Failed to update join group request notifications: %v", err)
+		return err
+	}
+
+	err = createJoinGroupResponseNotification(tx, groupID, int(secondReferenceID.Int64), status)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return err
+	}
+
 	// If the group invitation is accepted, add chat participant
 	if status == "accepted" {
 		return addUserToGroupChat(tx, groupID, int(secondReferenceID.Int64))
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"fmt"
+	"log"
+)
+
+type Liker struct {
+	UserID   int    `json:"user_id"`
+	FullName string `json:"full_name"`
+	Avatar   string `json:"avatar"`
+}
+
+type LikeList struct {
+	LikeCount int     `json:"like_count"`
+	Likers    []Liker `json:"likers"`
+}
+
+// LikePost stores a like from the user on the post. It returns true if the like is new,
+// so the caller only notifies the author once per user.
+func LikePost(postID, userID int) (bool, error) {
+	result, err := DB.Exec("INSERT OR IGNORE INTO post_likes (post_id, user_id) VALUES (?, ?)", postID, userID)
+	if err != nil {
+		log.Printf("Error inserting post like: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func UnlikePost(postID, userID int) error {
+	_, err := DB.Exec("DELETE FROM post_likes WHERE post_id = ? AND user_id = ?", postID, userID)
+	if err != nil {
+		log.Printf("Error deleting post like: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// LikeComment stores a like from the user on the comment. It returns true if the like is new.
+func LikeComment(commentID, userID int) (bool, error) {
+	result, err := DB.Exec("INSERT OR IGNORE INTO comment_likes (comment_id, user_id) VALUES (?, ?)", commentID, userID)
+	if err != nil {
+		log.Printf("Error inserting comment like: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func UnlikeComment(commentID, userID int) error {
+	_, err := DB.Exec("DELETE FROM comment_likes WHERE comment_id = ? AND user_id = ?", commentID, userID)
+	if err != nil {
+		log.Printf("Error deleting comment like: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetPostLikers returns the like count of a post and the users who liked it.
+// Users with a private profile are only listed to themselves and to their accepted followers,
+// but they are still part of the count.
+func GetPostLikers(postID, viewerID int) (LikeList, error) {
+	query := `
+	SELECT u.user_id, u.firstname || ' ' || u.lastname AS full_name, COALESCE(u.avatar, '')
+	FROM post_likes pl
+	JOIN users u ON pl.user_id = u.user_id
+	WHERE pl.post_id = ? AND ` + likerVisibleCondition + `
+	ORDER BY pl.created_at DESC`
+
+	return fetchLikers("SELECT COUNT(*) FROM post_likes WHERE post_id = ?", query, postID, viewerID)
+}
+
+// GetCommentLikers returns the like count of a comment and the users who liked it,
+// with the same profile privacy rules as GetPostLikers.
+func GetCommentLikers(commentID, viewerID int) (LikeList, error) {
+	query := `
+	SELECT u.user_id, u.firstname || ' ' || u.lastname AS full_name, COALESCE(u.avatar, '')
+	FROM comment_likes cl
+	JOIN users u ON cl.user_id = u.user_id
+	WHERE cl.comment_id = ? AND ` + likerVisibleCondition + `
+	ORDER BY cl.created_at DESC`
+
+	return fetchLikers("SELECT COUNT(*) FROM comment_likes WHERE comment_id = ?", query, commentID, viewerID)
+}
+
+// likerVisibleCondition matches liking users (aliased u) whose profile the viewer may see.
+// It expects the viewer's user ID bound twice.
+const likerVisibleCondition = `(u.profile_public = 1
+	OR u.user_id = ?
+	OR EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = u.user_id AND f.status = 'accepted'))`
+
+func fetchLikers(countQuery, query string, targetID, viewerID int) (LikeList, error) {
+	likes := LikeList{Likers: []Liker{}}
+
+	err := DB.QueryRow(countQuery, targetID).Scan(&likes.LikeCount)
+	if err != nil {
+		return LikeList{}, fmt.Errorf("fetchLikers: failed to count likes: %v", err)
+	}
+
+	rows, err := DB.Query(query, targetID, viewerID, viewerID)
+	if err != nil {
+		return LikeList{}, fmt.Errorf("fetchLikers: failed to query likers: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var liker Liker
+		if err := rows.Scan(&liker.UserID, &liker.FullName, &liker.Avatar); err != nil {
+			return LikeList{}, fmt.Errorf("fetchLikers: failed to scan liker row: %v", err)
+		}
+		likes.Likers = append(likes.Likers, liker)
+	}
+
+	if err := rows.Err(); err != nil {
+		return LikeList{}, fmt.Errorf("fetchLikers: error iterating over liker rows: %v", err)
+	}
+
+	return likes, nil
+}
+
+// attachPostLikes fills in the like count and the likedByMe flag of a post
+func attachPostLikes(post *Post, viewerID int) error {
+	query := `SELECT COUNT(*), COALESCE(SUM(user_id = ?), 0) FROM post_likes WHERE post_id = ?`
+
+	var likedByMe int
+	err := DB.QueryRow(query, viewerID, post.PostID).Scan(&post.LikeCount, &likedByMe)
+	if err != nil {
+		log.Printf("Error fetching likes for post %d: %v", post.PostID, err)
+		return err
+	}
+	post.LikedByMe = likedByMe > 0
+
+	return nil
+}
+
+// attachCommentLikes fills in the like count and the likedByMe flag of a comment
+func attachCommentLikes(comment *Comment, viewerID int) error {
+	query := `SELECT COUNT(*), COALESCE(SUM(user_id = ?), 0) FROM comment_likes WHERE comment_id = ?`
+
+	var likedByMe int
+	err := DB.QueryRow(query, viewerID, comment.CommentID).Scan(&comment.LikeCount, &likedByMe)
+	if err != nil {
+		log.Printf("Error fetching likes for comment %d: %v", comment.CommentID, err)
+		return err
+	}
+	comment.LikedByMe = likedByMe > 0
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	MaxPostMedia     = 10
+	MaxCommentMedia  = 4
+	maxAltTextLength = 500
+)
+
+// Media is one image of a post or comment. Data is only used when uploading, it holds the
+// image as a base64 data URL and is replaced by URL once the file is saved.
+type Media struct {
+	MediaID int    `json:"media_id,omitempty"`
+	URL     string `json:"url"`
+	AltText string `json:"alt_text"`
+	Width   *int   `json:"width,omitempty"`
+	Height  *int   `json:"height,omitempty"`
+	Data    string `json:"data,omitempty"`
+}
+
+// ValidateMedia trims the alt texts of new media items and checks their number against the limit
+func ValidateMedia(media []Media, limit int) error {
+	if len(media) > limit {
+		return fmt.Errorf("at most %d images can be attached", limit)
+	}
+
+	for i := range media {
+		if media[i].Data == "" {
+			return errors.New("every image needs data")
+		}
+
+		altText := strings.TrimSpace(media[i].AltText)
+		if len(altText) > maxAltTextLength {
+			return fmt.Errorf("alt texts can be at most %d characters", maxAltTextLength)
+		}
+		media[i].AltText = altText
+	}
+
+	return nil
+}
+
+// insertPostMedia stores the media items of a new post in the given order
+func insertPostMedia(postID int, media []Media) error {
+	return insertMedia("post_id", postID, media)
+}
+
+// insertCommentMedia stores the media items of a new comment in the given order
+func insertCommentMedia(commentID int, media []Media) error {
+	return insertMedia("comment_id", commentID, media)
+}
+
+func insertMedia(ownerColumn string, ownerID int, media []Media) error {
+	query := "INSERT INTO media (" + ownerColumn + ", position, url, alt_text, width, height) VALUES (?, ?, ?, ?, ?, ?)"
+
+	for i, item := range media {
+		_, err := DB.Exec(query, ownerID, i, item.URL, item.AltText, item.Width, item.Height)
+		if err != nil {
+			log.Printf("Error inserting media: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachPostMedia fills in the media items of a post
+func attachPostMedia(post *Post) error {
+	media, err := fetchMedia("post_id", post.PostID)
+	if err != nil {
+		return err
+	}
+	post.Media = media
+
+	return nil
+}
+
+// attachCommentMedia fills in the media items of a comment
+func attachCommentMedia(comment *Comment) error {
+	media, err := fetchMedia("comment_id", comment.CommentID)
+	if err != nil {
+		return err
+	}
+	comment.Media = media
+
+	return nil
+}
+
+func fetchMedia(ownerColumn string, ownerID int) ([]Media, error) {
+	query := "SELECT media_id, url, alt_text, width, height FROM media WHERE " + ownerColumn + " = ? ORDER BY position"
+
+	rows, err := DB.Query(query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("fetchMedia: failed to query media: %v", err)
+	}
+	defer rows.Close()
+
+	media := []Media{}
+	for rows.Next() {
+		var item Media
+		if err := rows.Scan(&item.MediaID, &item.URL, &item.AltText, &item.Width, &item.Height); err != nil {
+			return nil, fmt.Errorf("fetchMedia: failed to scan media row: %v", err)
+		}
+		media = append(media, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetchMedia: error iterating over media rows: %v", err)
+	}
+
+	return media, nil
+}
+
+// mediaURLs returns the URLs of the media items matching the condition, so their files can be
+// removed once the rows are deleted
+func mediaURLs(tx *sql.Tx, condition string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query("SELECT url FROM media WHERE "+condition, args...)
+	if err != nil {
+		return nil, fmt.Errorf("mediaURLs: failed to query media: %v", err)
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("mediaURLs: failed to scan media row: %v", err)
+		}
+		urls = append(urls, url)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("mediaURLs: error iterating over media rows: %v", err)
+	}
+
+	return urls, nil
+}
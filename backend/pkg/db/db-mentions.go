@@ -0,0 +1,158 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// mentionPattern matches @nickname at the start of the text or after a non-word character,
+// so e-mail addresses like dev@devgeek.dev are not taken for mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.]+)`)
+
+// ExtractMentions returns the distinct nicknames mentioned in a text, lowercased
+func ExtractMentions(text string) []string {
+	var nicknames []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		nickname := strings.ToLower(strings.TrimRight(match[1], "."))
+		if nickname == "" || seen[nickname] {
+			continue
+		}
+		seen[nickname] = true
+		nicknames = append(nicknames, nickname)
+	}
+
+	return nicknames
+}
+
+// CreatePostMentionNotifications notifies the users mentioned in a post who are allowed to see it
+func CreatePostMentionNotifications(postID int) error {
+	var authorID int
+	var content string
+	err := DB.QueryRow("SELECT user_id, content FROM posts WHERE post_id = ?", postID).Scan(&authorID, &content)
+	if err != nil {
+		log.Printf("Error querying post for mentions: %v", err)
+		return err
+	}
+
+	canSee := func(userID int) (bool, error) {
+		return CanViewPost(postID, userID)
+	}
+
+	return createMentionNotifications(authorID, content, "post_mention", "a post", postID, canSee)
+}
+
+// CreateCommentMentionNotifications notifies the users mentioned in a comment who are allowed to see its post
+func CreateCommentMentionNotifications(commentID int) error {
+	var authorID, postID int
+	var content string
+	err := DB.QueryRow("SELECT user_id, post_id, content FROM comments WHERE comment_id = ?", commentID).Scan(&authorID, &postID, &content)
+	if err != nil {
+		log.Printf("Error querying comment for mentions: %v", err)
+		return err
+	}
+
+	canSee := func(userID int) (bool, error) {
+		return CanViewPost(postID, userID)
+	}
+
+	// reference_id is the post, so the frontend can open the thread
+	return createMentionNotifications(authorID, content, "comment_mention", "a comment", postID, canSee)
+}
+
+// CreateChatMentionNotifications notifies the users mentioned in a chat message who take part in the chat
+func CreateChatMentionNotifications(chatID, senderID int, content string) error {
+	participants, err := GetChatParticipants(chatID)
+	if err != nil {
+		return err
+	}
+
+	canSee := func(userID int) (bool, error) {
+		for _, participant := range participants {
+			if participant.UserID == userID {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return createMentionNotifications(senderID, content, "chat_mention", "a chat message", chatID, canSee)
+}
+
+// createMentionNotifications resolves the mentioned nicknames to users and notifies every one of them
+// canSee allows, except the author
+func createMentionNotifications(authorID int, content, notifType, where string, referenceID int, canSee func(userID int) (bool, error)) error {
+	nicknames := ExtractMentions(content)
+	if len(nicknames) == 0 {
+		return nil
+	}
+
+	var fullName string
+	err := DB.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", authorID).Scan(&fullName)
+	if err != nil {
+		log.Printf("Error querying database for user's full name: %v", err)
+		return err
+	}
+
+	message := fmt.Sprintf("%s mentioned you in %s.", fullName, where)
+
+	notified := make(map[int]bool)
+	for _, nickname := range nicknames {
+		userIDs, err := getUserIDsByNickname(nickname)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			if userID == authorID || notified[userID] {
+				continue
+			}
+
+			visible, err := canSee(userID)
+			if err != nil {
+				return err
+			}
+			if !visible {
+				continue
+			}
+
+			_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id, second_reference_id)
+                              VALUES (?, ?, ?, ?, ?)`,
+				userID, notifType, message, referenceID, authorID)
+			if err != nil {
+				log.Printf("Error inserting mention notification: %v", err)
+				return err
+			}
+			notified[userID] = true
+		}
+	}
+
+	return nil
+}
+
+func getUserIDsByNickname(nickname string) ([]int, error) {
+	var userIDs []int
+
+	rows, err := DB.Query("SELECT user_id FROM users WHERE LOWER(nickname) = ?", nickname)
+	if err != nil {
+		return nil, fmt.Errorf("getUserIDsByNickname: failed to query users: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("getUserIDsByNickname: failed to scan user row: %v", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("getUserIDsByNickname: error iterating over user rows: %v", err)
+	}
+
+	return userIDs, nil
+}
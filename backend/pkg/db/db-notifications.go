@@ -130,6 +130,19 @@ func HandleNotificationResponse(userID, notificationID int, accepted bool) error
 
 	// Handle join_group_request notifications
 	if notifType == "join_group_request" && secondReferenceID.Valid {
+		// Only moderators and above decide on join requests
+		var role string
+		err = tx.QueryRow("SELECT role FROM group_members WHERE group_id = ? AND user_id = ? AND status = 'accepted'", referenceID, userID).Scan(&role)
+		if err != nil && err != sql.ErrNoRows {
+			tx.Rollback() // Roll back in case of error
+			log.Printf("Failed to fetch group role: %v", err)
+			return err
+		}
+		if role == "" || !GroupRoleOutranks(role, GroupRoleMember) {
+			tx.Rollback()
+			return ErrGroupPermission
+		}
+
 		err = handleJoinGroup(tx, referenceID, secondReferenceID, status)
 		if err != nil {
 			tx.Rollback() // Roll back in case of error
@@ -169,10 +182,11 @@ func CreateFollowRequestNotification(followerID, followingID int) error {
 	return nil
 }
 
-func CreateGroupInvitationNotification(invitingUserID, invitedUserID, groupID int) error {
+// createGroupInvitationNotification invites the user to the group within the invitation transaction
+func createGroupInvitationNotification(tx *sql.Tx, invitingUserID, invitedUserID, groupID int) error {
 	// First, retrieve the title of the group
 	var groupTitle string
-	err := DB.QueryRow("SELECT title FROM groups WHERE group_id = ?", groupID).Scan(&groupTitle)
+	err := tx.QueryRow("SELECT title FROM groups WHERE group_id = ?", groupID).Scan(&groupTitle)
 	if err != nil {
 		log.Printf("Error querying database for group title: %v", err)
 		return err
@@ -180,7 +194,7 @@ func CreateGroupInvitationNotification(invitingUserID, invitedUserID, groupID in
 
 	// Then, retrieve the full name of the inviting user
 	var fullName string
-	err = DB.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", invitingUserID).Scan(&fullName)
+	err = tx.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", invitingUserID).Scan(&fullName)
 	if err != nil {
 		log.Printf("Error querying database for user's full name: %v", err)
 		return err
@@ -190,7 +204,7 @@ func CreateGroupInvitationNotification(invitingUserID, invitedUserID, groupID in
 	message := fmt.Sprintf("%s has invited you to join the group '%s'.", fullName, groupTitle)
 
 	// Insert the invitation notification into the notifications table
-	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id)
+	_, err = tx.Exec(`INSERT INTO notifications (user_id, type, message, reference_id)
                       VALUES (?, 'group_invitation', ?, ?)`,
 		invitedUserID, message, groupID)
 	if err != nil {
@@ -201,13 +215,13 @@ func CreateGroupInvitationNotification(invitingUserID, invitedUserID, groupID in
 	return nil
 }
 
+// CreateJoinGroupRequestNotification sends the join request to every moderator, admin and the owner of the group
 func CreateJoinGroupRequestNotification(requestingUserID, groupID int) error {
 
 	var groupTitle string
-	var creatorUserID int
-	err := DB.QueryRow("SELECT title, user_id FROM groups WHERE group_id = ?", groupID).Scan(&groupTitle, &creatorUserID)
+	err := DB.QueryRow("SELECT title FROM groups WHERE group_id = ?", groupID).Scan(&groupTitle)
 	if err != nil {
-		log.Printf("Error querying database for group title and creator ID: %v", err)
+		log.Printf("Error querying database for group title: %v", err)
 		return err
 	}
 
@@ -222,8 +236,10 @@ func CreateJoinGroupRequestNotification(requestingUserID, groupID int) error {
 
 	// Including both reference_id (for the group) and second_reference_id (for the requesting user)
 	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id, second_reference_id)
-                      VALUES (?, 'join_group_request', ?, ?, ?)`,
-		creatorUserID, message, groupID, requestingUserID)
+                      SELECT user_id, 'join_group_request', ?, ?, ?
+                      FROM group_members
+                      WHERE group_id = ? AND status = 'accepted' AND role IN ('owner', 'admin', 'moderator')`,
+		message, groupID, requestingUserID, groupID)
 	if err != nil {
 		log.Printf("Error inserting join group request notification: %v", err)
 		return err
@@ -232,6 +248,31 @@ func CreateJoinGroupRequestNotification(requestingUserID, groupID int) error {
 	return nil
 }
 
+// createJoinGroupResponseNotification tells the user whether their request to join the group was accepted
+func createJoinGroupResponseNotification(tx *sql.Tx, groupID, userID int, status string) error {
+	var groupTitle string
+	err := tx.QueryRow("SELECT title FROM groups WHERE group_id = ?", groupID).Scan(&groupTitle)
+	if err != nil {
+		log.Printf("Error querying database for group title: %v", err)
+		return err
+	}
+
+	message := fmt.Sprintf("Your request to join the group '%s' was declined.", groupTitle)
+	if status == "accepted" {
+		message = fmt.Sprintf("Your request to join the group '%s' was accepted.", groupTitle)
+	}
+
+	_, err = tx.Exec(`INSERT INTO notifications (user_id, type, message, reference_id)
+                      VALUES (?, 'join_group_response', ?, ?)`,
+		userID, message, groupID)
+	if err != nil {
+		log.Printf("Error inserting join group response notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 func CreateEventNotification(groupID int) error {
 	var groupTitle string
 	err := DB.QueryRow("SELECT title FROM groups WHERE group_id = ?", groupID).Scan(&groupTitle)
@@ -264,6 +305,232 @@ func CreateEventNotification(groupID int) error {
 	return nil
 }
 
+func CreatePostLikeNotification(likerID, postID int) error {
+	var authorID int
+	err := DB.QueryRow("SELECT user_id FROM posts WHERE post_id = ?", postID).Scan(&authorID)
+	if err != nil {
+		log.Printf("Error querying database for post author: %v", err)
+		return err
+	}
+
+	// No need to tell users about their own likes
+	if authorID == likerID {
+		return nil
+	}
+
+	var fullName string
+	err = DB.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", likerID).Scan(&fullName)
+	if err != nil {
+		log.Printf("Error querying database for user's full name: %v", err)
+		return err
+	}
+
+	message := fmt.Sprintf("%s liked your post.", fullName)
+
+	// reference_id is the liked post, second_reference_id the user who liked it
+	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id, second_reference_id)
+                      VALUES (?, 'post_like', ?, ?, ?)`,
+		authorID, message, postID, likerID)
+	if err != nil {
+		log.Printf("Error inserting post like notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func CreateCommentLikeNotification(likerID, commentID int) error {
+	var authorID, postID int
+	err := DB.QueryRow("SELECT user_id, post_id FROM comments WHERE comment_id = ?", commentID).Scan(&authorID, &postID)
+	if err != nil {
+		log.Printf("Error querying database for comment author: %v", err)
+		return err
+	}
+
+	if authorID == likerID {
+		return nil
+	}
+
+	var fullName string
+	err = DB.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", likerID).Scan(&fullName)
+	if err != nil {
+		log.Printf("Error querying database for user's full name: %v", err)
+		return err
+	}
+
+	message := fmt.Sprintf("%s liked your comment.", fullName)
+
+	// reference_id is the post of the comment, so the frontend can link to it
+	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id, second_reference_id)
+                      VALUES (?, 'comment_like', ?, ?, ?)`,
+		authorID, message, postID, likerID)
+	if err != nil {
+		log.Printf("Error inserting comment like notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func CreatePostCommentNotification(commenterID, commentID int) error {
+	var postID, authorID int
+	var parentAuthorID sql.NullInt64
+	err := DB.QueryRow(`SELECT p.post_id, p.user_id, parent.user_id
+                        FROM comments c
+                        JOIN posts p ON c.post_id = p.post_id
+                        LEFT JOIN comments parent ON c.parent_comment_id = parent.comment_id
+                        WHERE c.comment_id = ?`, commentID).Scan(&postID, &authorID, &parentAuthorID)
+	if err != nil {
+		log.Printf("Error querying database for post author: %v", err)
+		return err
+	}
+
+	// Authors don't need to hear about their own comments, and a reply to the
+	// author's own comment is already covered by the comment_reply notification
+	if authorID == commenterID || (parentAuthorID.Valid && int(parentAuthorID.Int64) == authorID) {
+		return nil
+	}
+
+	var fullName string
+	err = DB.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", commenterID).Scan(&fullName)
+	if err != nil {
+		log.Printf("Error querying database for user's full name: %v", err)
+		return err
+	}
+
+	message := fmt.Sprintf("%s commented on your post.", fullName)
+
+	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id, second_reference_id)
+                      VALUES (?, 'post_comment', ?, ?, ?)`,
+		authorID, message, postID, commenterID)
+	if err != nil {
+		log.Printf("Error inserting post comment notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func CreateCommentReplyNotification(replierID, parentCommentID int) error {
+	var authorID, postID int
+	err := DB.QueryRow("SELECT user_id, post_id FROM comments WHERE comment_id = ?", parentCommentID).Scan(&authorID, &postID)
+	if err != nil {
+		log.Printf("Error querying database for comment author: %v", err)
+		return err
+	}
+
+	// Replying to your own comment is not worth a notification
+	if authorID == replierID {
+		return nil
+	}
+
+	var fullName string
+	err = DB.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", replierID).Scan(&fullName)
+	if err != nil {
+		log.Printf("Error querying database for user's full name: %v", err)
+		return err
+	}
+
+	message := fmt.Sprintf("%s replied to your comment.", fullName)
+
+	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id, second_reference_id)
+                      VALUES (?, 'comment_reply', ?, ?, ?)`,
+		authorID, message, postID, replierID)
+	if err != nil {
+		log.Printf("Error inserting comment reply notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func CreatePostReshareNotification(resharerID, reshareID int) error {
+	var authorID int
+	err := DB.QueryRow(`SELECT o.user_id FROM posts r JOIN posts o ON r.reshared_post_id = o.post_id
+                        WHERE r.post_id = ?`, reshareID).Scan(&authorID)
+	if err != nil {
+		log.Printf("Error querying database for original post author: %v", err)
+		return err
+	}
+
+	// No need to tell users about resharing their own posts
+	if authorID == resharerID {
+		return nil
+	}
+
+	var fullName string
+	err = DB.QueryRow("SELECT firstname || ' ' || lastname FROM users WHERE user_id = ?", resharerID).Scan(&fullName)
+	if err != nil {
+		log.Printf("Error querying database for user's full name: %v", err)
+		return err
+	}
+
+	message := fmt.Sprintf("%s reshared your post.", fullName)
+
+	// reference_id is the reshare, so the author can see it in context, second_reference_id the user who reshared
+	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id, second_reference_id)
+                      VALUES (?, 'post_reshare', ?, ?, ?)`,
+		authorID, message, reshareID, resharerID)
+	if err != nil {
+		log.Printf("Error inserting post reshare notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// CreatePostPublishedNotification tells the author that a scheduled post went online
+func CreatePostPublishedNotification(postID int) error {
+	var authorID int
+	err := DB.QueryRow("SELECT user_id FROM posts WHERE post_id = ?", postID).Scan(&authorID)
+	if err != nil {
+		log.Printf("Error querying database for post author: %v", err)
+		return err
+	}
+
+	_, err = DB.Exec(`INSERT INTO notifications (user_id, type, message, reference_id)
+                      VALUES (?, 'post_published', 'Your scheduled post was published.', ?)`,
+		authorID, postID)
+	if err != nil {
+		log.Printf("Error inserting post published notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// createGroupPostReviewNotification tells the author whether their group post was approved. reference_id is
+// the post when it was approved and the group when it was rejected, as rejected posts can't be opened.
+func createGroupPostReviewNotification(tx *sql.Tx, groupID, postID int, approved bool) error {
+	var groupTitle string
+	var authorID int
+	err := tx.QueryRow("SELECT g.title, p.user_id FROM posts p JOIN groups g ON p.group_id = g.group_id WHERE p.post_id = ?", postID).
+		Scan(&groupTitle, &authorID)
+	if err != nil {
+		log.Printf("Error querying database for group post: %v", err)
+		return err
+	}
+
+	notifType := "group_post_rejected"
+	message := fmt.Sprintf("Your post in the group '%s' was rejected.", groupTitle)
+	referenceID := groupID
+	if approved {
+		notifType = "group_post_approved"
+		message = fmt.Sprintf("Your post in the group '%s' was approved.", groupTitle)
+		referenceID = postID
+	}
+
+	_, err = tx.Exec(`INSERT INTO notifications (user_id, type, message, reference_id)
+                      VALUES (?, ?, ?, ?)`,
+		authorID, notifType, message, referenceID)
+	if err != nil {
+		log.Printf("Error inserting group post review notification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 func GetAcceptedGroupMembers(groupID int, DB *sql.DB) ([]int, error) {
 	var memberIDs []int
 	rows, err := DB.Query("SELECT user_id FROM group_members WHERE group_id = ? AND status = 'accepted'", groupID)
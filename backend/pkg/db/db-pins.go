@@ -0,0 +1,96 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+const (
+	MaxProfilePins = 3
+	MaxGroupPins   = 3
+)
+
+var ErrPinLimitReached = errors.New("pin limit reached, unpin a post first")
+
+// GetPinTarget returns the author and group of a published post, the group is nil for posts
+// outside groups. It returns ErrPostNotFound if there is no such post.
+func GetPinTarget(postID int) (int, *int, error) {
+	var authorID int
+	var groupID *int
+	err := DB.QueryRow("SELECT user_id, group_id FROM posts WHERE post_id = ? AND status = 'published' AND approval_status = 'approved'", postID).Scan(&authorID, &groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, ErrPostNotFound
+		}
+		return 0, nil, fmt.Errorf("GetPinTarget: failed to fetch post: %v", err)
+	}
+
+	return authorID, groupID, nil
+}
+
+// PinPost pins a post to its author's profile or, for a group post, to the group.
+// Pinning a pinned post does nothing.
+func PinPost(postID int) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	var authorID int
+	var groupID *int
+	var pinned bool
+	err = tx.QueryRow("SELECT user_id, group_id, pinned_at IS NOT NULL FROM posts WHERE post_id = ?", postID).Scan(&authorID, &groupID, &pinned)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("PinPost: failed to fetch post: %v", err)
+	}
+
+	if pinned {
+		tx.Rollback()
+		return nil
+	}
+
+	// The pins are counted inside the transaction, so concurrent pins can't exceed the limit
+	var count, limit int
+	if groupID != nil {
+		limit = MaxGroupPins
+		err = tx.QueryRow("SELECT COUNT(*) FROM posts WHERE group_id = ? AND pinned_at IS NOT NULL", *groupID).Scan(&count)
+	} else {
+		limit = MaxProfilePins
+		err = tx.QueryRow("SELECT COUNT(*) FROM posts WHERE user_id = ? AND group_id IS NULL AND pinned_at IS NOT NULL", authorID).Scan(&count)
+	}
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return fmt.Errorf("PinPost: failed to count pinned posts: %v", err)
+	}
+
+	if count >= limit {
+		tx.Rollback()
+		return ErrPinLimitReached
+	}
+
+	_, err = tx.Exec("UPDATE posts SET pinned_at = CURRENT_TIMESTAMP WHERE post_id = ?", postID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error pinning post: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func UnpinPost(postID int) error {
+	_, err := DB.Exec("UPDATE posts SET pinned_at = NULL WHERE post_id = ?", postID)
+	if err != nil {
+		log.Printf("Error unpinning post: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 10
+	maxPollOptionLength = 200
+)
+
+var (
+	ErrPollNotFound      = errors.New("poll not found")
+	ErrPollClosed        = errors.New("poll is closed")
+	ErrInvalidPollOption = errors.New("invalid poll option")
+	ErrSingleChoicePoll  = errors.New("single choice poll allows one vote per user")
+)
+
+// Poll is attached to a post, the post content is the question. When creating a poll only
+// the option texts, MultipleChoice and ClosesAt are read.
+type Poll struct {
+	MultipleChoice bool         `json:"multiple_choice"`
+	ClosesAt       *time.Time   `json:"closes_at,omitempty"`
+	Closed         bool         `json:"closed"`
+	Options        []PollOption `json:"options"`
+	VoterCount     int          `json:"voter_count"`
+}
+
+type PollOption struct {
+	OptionID  int    `json:"option_id"`
+	Text      string `json:"text"`
+	VoteCount int    `json:"vote_count"`
+	VotedByMe bool   `json:"voted_by_me"`
+}
+
+type PollVoteRequest struct {
+	OptionIDs []int `json:"option_ids"`
+}
+
+// ValidatePoll trims the option texts of a new poll and checks the number of options and the closing time
+func ValidatePoll(poll *Poll) error {
+	if len(poll.Options) < MinPollOptions || len(poll.Options) > MaxPollOptions {
+		return fmt.Errorf("a poll needs between %d and %d options", MinPollOptions, MaxPollOptions)
+	}
+
+	for i := range poll.Options {
+		text := strings.TrimSpace(poll.Options[i].Text)
+		if text == "" || len(text) > maxPollOptionLength {
+			return fmt.Errorf("poll options must be between 1 and %d characters", maxPollOptionLength)
+		}
+		poll.Options[i].Text = text
+	}
+
+	if poll.ClosesAt != nil && !poll.ClosesAt.After(time.Now()) {
+		return errors.New("the closing time of a poll must be in the future")
+	}
+
+	return nil
+}
+
+// insertPoll stores the poll and its options for a new post
+func insertPoll(postID int, poll Poll) error {
+	_, err := DB.Exec("INSERT INTO polls (post_id, multiple_choice, closes_at) VALUES (?, ?, ?)", postID, poll.MultipleChoice, formatTimestamp(poll.ClosesAt))
+	if err != nil {
+		log.Printf("Error inserting poll: %v", err)
+		return err
+	}
+
+	for i, option := range poll.Options {
+		_, err := DB.Exec("INSERT INTO poll_options (post_id, position, text) VALUES (?, ?, ?)", postID, i, option.Text)
+		if err != nil {
+			log.Printf("Error inserting poll option: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachPoll fills in the poll of a post, with the vote counts and the viewer's votes
+func attachPoll(post *Post, viewerID int) error {
+	var poll Poll
+	var closesAt sql.NullTime
+	err := DB.QueryRow(`SELECT multiple_choice, closes_at, closes_at IS NOT NULL AND closes_at <= CURRENT_TIMESTAMP,
+	                           (SELECT COUNT(DISTINCT user_id) FROM poll_votes v WHERE v.post_id = polls.post_id)
+	                    FROM polls WHERE post_id = ?`, post.PostID).Scan(&poll.MultipleChoice, &closesAt, &poll.Closed, &poll.VoterCount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Printf("Error fetching poll for post %d: %v", post.PostID, err)
+		return err
+	}
+
+	if closesAt.Valid {
+		poll.ClosesAt = &closesAt.Time
+	}
+
+	query := `SELECT o.option_id, o.text, COUNT(v.user_id), COALESCE(SUM(v.user_id = ?), 0)
+              FROM poll_options o
+              LEFT JOIN poll_votes v ON v.option_id = o.option_id
+              WHERE o.post_id = ?
+              GROUP BY o.option_id
+              ORDER BY o.position`
+
+	rows, err := DB.Query(query, viewerID, post.PostID)
+	if err != nil {
+		return fmt.Errorf("attachPoll: failed to query options: %v", err)
+	}
+	defer rows.Close()
+
+	poll.Options = []PollOption{}
+	for rows.Next() {
+		var option PollOption
+		var votedByMe int
+		if err := rows.Scan(&option.OptionID, &option.Text, &option.VoteCount, &votedByMe); err != nil {
+			return fmt.Errorf("attachPoll: failed to scan option row: %v", err)
+		}
+		option.VotedByMe = votedByMe > 0
+		poll.Options = append(poll.Options, option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("attachPoll: error iterating over option rows: %v", err)
+	}
+
+	post.Poll = &poll
+	return nil
+}
+
+// VotePoll replaces the user's votes on a poll with the given options. An empty list withdraws the vote.
+func VotePoll(postID, userID int, optionIDs []int) error {
+	var multipleChoice, closed bool
+	err := DB.QueryRow(`SELECT multiple_choice, closes_at IS NOT NULL AND closes_at <= CURRENT_TIMESTAMP
+	                    FROM polls WHERE post_id = ?`, postID).Scan(&multipleChoice, &closed)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPollNotFound
+		}
+		return fmt.Errorf("VotePoll: failed to fetch poll: %v", err)
+	}
+
+	if closed {
+		return ErrPollClosed
+	}
+
+	if !multipleChoice && len(optionIDs) > 1 {
+		return ErrSingleChoicePoll
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM poll_votes WHERE post_id = ? AND user_id = ?", postID, userID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting poll votes: %v", err)
+		return err
+	}
+
+	seen := make(map[int]bool)
+	for _, optionID := range optionIDs {
+		if seen[optionID] {
+			continue
+		}
+		seen[optionID] = true
+
+		var valid bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM poll_options WHERE option_id = ? AND post_id = ?)", optionID, postID).Scan(&valid)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return fmt.Errorf("VotePoll: failed to check option: %v", err)
+		}
+		if !valid {
+			tx.Rollback() // Roll back in case of error
+			return ErrInvalidPollOption
+		}
+
+		// The poll_votes_check trigger enforces the rules again, in case of concurrent votes
+		_, err = tx.Exec("INSERT INTO poll_votes (post_id, option_id, user_id) VALUES (?, ?, ?)", postID, optionID, userID)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			log.Printf("Error inserting poll vote: %v", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -3,29 +3,89 @@ package db
 import (
 	"database/sql"
 	"log"
+	"strings"
+	"time"
 )
 
 type Post struct {
-	PostID       int     `json:"post_id,omitempty"`
-	UserID       int     `json:"user_id"`
-	GroupID      *int    `json:"group_id,omitempty"`
-	Content      string  `json:"content"`
-	PostImage    *string `json:"post_image,omitempty"`
-	PrivacyLevel *string `json:"privacy_level,omitempty"`
-	CreatedAt    string  `json:"created_at"`
-	ViewerIDs    []int   `json:"viewer_ids,omitempty"`
-	FullName     string  `json:"full_name,omitempty"`
+	PostID         int     `json:"post_id,omitempty"`
+	UserID         int     `json:"user_id"`
+	GroupID        *int    `json:"group_id,omitempty"`
+	Content        string  `json:"content"`
+	PostImage      *string `json:"post_image,omitempty"`
+	PrivacyLevel   *string `json:"privacy_level,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	ViewerIDs      []int   `json:"viewer_ids,omitempty"`
+	FullName       string  `json:"full_name,omitempty"`
+	LikeCount      int     `json:"like_count"`
+	LikedByMe      bool    `json:"liked_by_me"`
+	CommentPolicy  string  `json:"comment_policy"`
+	CommentsLocked bool    `json:"comments_locked"`
+	ResharedPostID *int    `json:"reshared_post_id,omitempty"`
+	// ResharedPost is the original of a reshare, left nil when it was deleted or the viewer
+	// may not see it, in which case ReshareUnavailable is set
+	ResharedPost       *Post `json:"reshared_post,omitempty"`
+	ReshareUnavailable bool  `json:"reshare_unavailable,omitempty"`
+	ReshareCount       int   `json:"reshare_count"`
+	SavedByMe          bool  `json:"saved_by_me"`
+	Poll               *Poll `json:"poll,omitempty"`
+	// Media lists the images of the post in order, PostImage stays the URL of the first one
+	Media []Media `json:"media"`
+	// Status is published, draft or scheduled, scheduled posts are published at PublishAt
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// Pinned posts are listed first on the author's profile, or in the group for group posts
+	Pinned bool `json:"pinned"`
+	// ApprovalStatus is pending or rejected for group posts held for review, approved otherwise
+	ApprovalStatus string `json:"approval_status"`
+}
+
+// visiblePostCondition matches published posts (aliased p) the viewer is allowed to see.
+// It expects the viewer's user ID bound four times, see visiblePostArgs.
+const visiblePostCondition = `(p.status = 'published' AND p.approval_status = 'approved' AND (p.user_id = ?
+	OR (p.group_id IS NULL AND p.privacy_level = 'public')
+	OR (p.group_id IS NULL AND p.privacy_level = 'private'
+		AND EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = p.user_id AND f.status = 'accepted'))
+	OR (p.group_id IS NULL
+		AND EXISTS (SELECT 1 FROM post_viewers pv WHERE pv.post_id = p.post_id AND pv.viewer_id = ?))
+	OR (p.group_id IS NOT NULL
+		AND (EXISTS (SELECT 1 FROM groups g WHERE g.group_id = p.group_id AND g.visibility = 'public')
+			OR EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = p.group_id AND gm.user_id = ? AND gm.status = 'accepted')))))`
+
+func visiblePostArgs(viewerID int) []interface{} {
+	return []interface{}{viewerID, viewerID, viewerID, viewerID}
+}
+
+// postDetailColumns are the columns of a post (aliased p) besides its content that every post query
+// selects after its own columns, see postDetailDest
+const postDetailColumns = `p.comment_policy, p.comments_locked, p.reshared_post_id, p.status, p.publish_at,
+	p.pinned_at IS NOT NULL, p.approval_status,
+	(SELECT COUNT(*) FROM posts r WHERE r.reshared_post_id = p.post_id AND r.status = 'published')`
+
+// postDetailDest returns the scan destinations of postDetailColumns
+func postDetailDest(post *Post) []interface{} {
+	return []interface{}{&post.CommentPolicy, &post.CommentsLocked, &post.ResharedPostID, &post.Status, &post.PublishAt,
+		&post.Pinned, &post.ApprovalStatus, &post.ReshareCount}
 }
 
 func InsertPost(post Post) (int, error) {
-	statement, err := DB.Prepare(`INSERT INTO posts (user_id, group_id, content, post_image, privacy_level) VALUES (?, ?, ?, ?, ?)`)
+	if post.CommentPolicy == "" {
+		post.CommentPolicy = CommentPolicyEveryone
+	}
+	if post.Status == "" {
+		post.Status = PostPublished
+	}
+
+	statement, err := DB.Prepare(`INSERT INTO posts (user_id, group_id, content, post_image, privacy_level, comment_policy, reshared_post_id, status, publish_at)
+	                              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		log.Printf("Error preparing insert statement: %v", err)
 		return 0, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(post.UserID, post.GroupID, post.Content, post.PostImage, post.PrivacyLevel)
+	result, err := statement.Exec(post.UserID, post.GroupID, post.Content, post.PostImage, post.PrivacyLevel, post.CommentPolicy, post.ResharedPostID,
+		post.Status, formatTimestamp(post.PublishAt))
 	if err != nil {
 		log.Printf("Error executing insert statement: %v", err)
 		return 0, err
@@ -39,6 +99,23 @@ func InsertPost(post Post) (int, error) {
 
 	// fmt.Println("Post inserted to DB successfully with postID:", postID)
 
+	err = insertPostMedia(int(postID), post.Media)
+	if err != nil {
+		return 0, err
+	}
+
+	if post.Poll != nil {
+		err = insertPoll(int(postID), *post.Poll)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = indexPostTags(int(postID), post.Content)
+	if err != nil {
+		return 0, err
+	}
+
 	return int(postID), nil
 }
 
@@ -47,10 +124,10 @@ func GetPostsForFeed(userID int) ([]Post, error) {
 
 	// Fetch public posts
 	publicPostsQuery := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at, 
-                         (u.firstname || ' ' || u.lastname) AS full_name
+                         (u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
                          FROM posts p
                          JOIN users u ON p.user_id = u.user_id
-                         WHERE p.privacy_level = 'public'`
+                         WHERE p.privacy_level = 'public' AND p.status = 'published'`
 
 	// Use a helper function to reduce code duplication
 	publicPosts, err := fetchPosts(publicPostsQuery, userID)
@@ -63,11 +140,11 @@ func GetPostsForFeed(userID int) ([]Post, error) {
 
 	// Fetch private posts
 	privatePostsQuery := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at, 
-                      (u.firstname || ' ' || u.lastname) AS full_name
+                      (u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
                       FROM posts p
                       JOIN follows f ON p.user_id = f.following_id
                       JOIN users u ON p.user_id = u.user_id
-                      WHERE f.follower_id = ? AND f.status = 'accepted' AND p.privacy_level = 'private' AND p.group_id IS NULL`
+                      WHERE f.follower_id = ? AND f.status = 'accepted' AND p.privacy_level = 'private' AND p.group_id IS NULL AND p.status = 'published'`
 
 	privatePosts, err := fetchPosts(privatePostsQuery, userID)
 	if err != nil {
@@ -79,11 +156,11 @@ func GetPostsForFeed(userID int) ([]Post, error) {
 
 	// Fetch friends (viewer) posts
 	viewerPostsQuery := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at, 
-                         (u.firstname || ' ' || u.lastname) AS full_name
+                         (u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
                          FROM posts p
                          JOIN post_viewers pv ON p.post_id = pv.post_id
                          JOIN users u ON p.user_id = u.user_id
-                         WHERE pv.viewer_id = ?`
+                         WHERE pv.viewer_id = ? AND p.status = 'published'`
 
 	viewerPosts, err := fetchPosts(viewerPostsQuery, userID)
 	if err != nil {
@@ -95,10 +172,10 @@ func GetPostsForFeed(userID int) ([]Post, error) {
 
 	// Fetch friends (viewer) posts
 	creatorPostsQuery := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at,
-	(u.firstname || ' ' || u.lastname) AS full_name
+	(u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
 	FROM posts p
 	JOIN users u ON p.user_id = u.user_id
-	WHERE p.user_id = ? AND p.privacy_level = 'friends'
+	WHERE p.user_id = ? AND p.privacy_level = 'friends' AND p.status = 'published'
 	`
 
 	creatorPosts, err := fetchPosts(creatorPostsQuery, userID)
@@ -109,6 +186,10 @@ func GetPostsForFeed(userID int) ([]Post, error) {
 	// Append the results of friends (viewer) posts to the initial posts slice
 	posts = append(posts, creatorPosts...)
 
+	if err := fillPostDetails(posts, userID); err != nil {
+		return nil, err
+	}
+
 	// // Fetch friends (viewer) posts
 	// creatorPrivateQuery := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at,
 	// (u.firstname || ' ' || u.lastname) AS full_name
@@ -129,10 +210,10 @@ func GetPostsForFeed(userID int) ([]Post, error) {
 }
 
 // fetchPosts is a helper function to execute the provided query and fetch posts
-func fetchPosts(query string, userID int) ([]Post, error) {
+func fetchPosts(query string, args ...interface{}) ([]Post, error) {
 	var posts []Post
 
-	rows, err := DB.Query(query, userID)
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +221,8 @@ func fetchPosts(query string, userID int) ([]Post, error) {
 
 	for rows.Next() {
 		var post Post
-		err := rows.Scan(&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.PrivacyLevel, &post.CreatedAt, &post.FullName)
+		dest := []interface{}{&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.PrivacyLevel, &post.CreatedAt, &post.FullName}
+		err := rows.Scan(append(dest, postDetailDest(&post)...)...)
 		if err != nil {
 			return nil, err
 		}
@@ -161,7 +243,11 @@ func fetchPosts(query string, userID int) ([]Post, error) {
 func GetPostsForProfile(userID int) ([]Post, error) {
 	var posts []Post
 
-	query := "SELECT post_id, user_id, group_id, content, post_image, privacy_level, created_at FROM posts WHERE user_id = ?"
+	// Group posts are pinned in their group, not on the profile. Group posts waiting for review are shown to their author.
+	query := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at, ` + postDetailColumns + `
+	          FROM posts p
+	          WHERE p.user_id = ? AND p.status = 'published' AND p.approval_status != 'rejected'
+	          ORDER BY CASE WHEN p.group_id IS NULL THEN p.pinned_at END DESC, p.created_at DESC`
 
 	rows, err := DB.Query(query, userID)
 	if err != nil {
@@ -172,7 +258,8 @@ func GetPostsForProfile(userID int) ([]Post, error) {
 
 	for rows.Next() {
 		var post Post
-		err := rows.Scan(&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.PrivacyLevel, &post.CreatedAt)
+		dest := []interface{}{&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.PrivacyLevel, &post.CreatedAt}
+		err := rows.Scan(append(dest, postDetailDest(&post)...)...)
 		if err != nil {
 			log.Printf("Error scanning post row: %v", err)
 			return nil, err
@@ -185,24 +272,30 @@ func GetPostsForProfile(userID int) ([]Post, error) {
 		return nil, err
 	}
 
+	if err := fillPostDetails(posts, userID); err != nil {
+		return nil, err
+	}
+
 	return posts, nil
 }
 
 func GetPostsForUser(userID, loggedID int) ([]Post, error) {
 	var posts []Post
 
+	// Group posts are stored as private, visiblePostCondition applies the group's visibility to them instead.
+	// They are pinned in their group, not on the profile.
 	query := `
-	SELECT post_id, user_id, group_id, content, post_image, privacy_level, created_at
-	FROM posts
-	WHERE user_id = ? 
-	  AND privacy_level IS NOT NULL 
-	  AND (privacy_level = 'public' 
-	       OR user_id = ? 
-	       OR EXISTS (SELECT 1 FROM follows WHERE follower_id = ? AND following_id = posts.user_id AND status = 'accepted') 
-	       OR EXISTS (SELECT 1 FROM post_viewers WHERE post_id = posts.post_id AND viewer_id = ?))
+	SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at, ` + postDetailColumns + `
+	FROM posts p
+	WHERE p.user_id = ? 
+	  AND p.privacy_level IS NOT NULL 
+	  AND ` + visiblePostCondition + `
+	ORDER BY CASE WHEN p.group_id IS NULL THEN p.pinned_at END DESC, p.created_at DESC
 	`
+	args := append([]interface{}{userID}, visiblePostArgs(loggedID)...)
+
 	// Query for posts belonging to the specified user and considering privacy settings
-	rows, err := DB.Query(query, userID, userID, loggedID, loggedID)
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		log.Printf("Error querying database for posts: %v", err)
 		return nil, err
@@ -213,7 +306,8 @@ func GetPostsForUser(userID, loggedID int) ([]Post, error) {
 	for rows.Next() {
 		var post Post
 
-		err := rows.Scan(&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.PrivacyLevel, &post.CreatedAt)
+		dest := []interface{}{&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.PrivacyLevel, &post.CreatedAt}
+		err := rows.Scan(append(dest, postDetailDest(&post)...)...)
 		if err != nil {
 			log.Printf("Error scanning post row: %v", err)
 			return nil, err
@@ -226,6 +320,10 @@ func GetPostsForUser(userID, loggedID int) ([]Post, error) {
 		return nil, err
 	}
 
+	if err := fillPostDetails(posts, loggedID); err != nil {
+		return nil, err
+	}
+
 	return posts, nil
 }
 
@@ -247,33 +345,42 @@ func InsertPostViewers(postID int, viewerIDs []int) error {
 }
 
 // to display one post
-func GetPostByPostID(postID int) (Post, error) {
+func GetPostByPostID(postID, viewerID int) (Post, error) {
 	var post Post
 
 	query := `
-	SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.created_at, p.privacy_level, CONCAT(u.firstname, ' ', u.lastname) AS full_name
+	SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.created_at, p.privacy_level, CONCAT(u.firstname, ' ', u.lastname) AS full_name,
+	` + postDetailColumns + `
 	FROM posts p
 	JOIN users u ON p.user_id = u.user_id
 	WHERE p.post_id = ?
 `
-	err := DB.QueryRow(query, postID).Scan(&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.CreatedAt, &post.PrivacyLevel, &post.FullName)
+	dest := []interface{}{&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.CreatedAt, &post.PrivacyLevel, &post.FullName}
+	err := DB.QueryRow(query, postID).Scan(append(dest, postDetailDest(&post)...)...)
 	if err != nil {
 		log.Printf("Error querying database for post: %v", err)
 		return Post{}, err
 	}
 
-	return post, nil
+	posts := []Post{post}
+	if err := fillPostDetails(posts, viewerID); err != nil {
+		return Post{}, err
+	}
+
+	return posts[0], nil
 }
 
 // GetPostsByGroupID retrieves posts that belong to a specific group by its groupID
-func GetPostsByGroupID(groupID int) ([]Post, error) {
+func GetPostsByGroupID(groupID, viewerID int) ([]Post, error) {
 	var posts []Post
 
 	query := `
-    SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.created_at, CONCAT(u.firstname, ' ', u.lastname) AS full_name
+    SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.created_at, CONCAT(u.firstname, ' ', u.lastname) AS full_name,
+    ` + postDetailColumns + `
     FROM posts p
     JOIN users u ON p.user_id = u.user_id
-    WHERE p.group_id = ?`
+    WHERE p.group_id = ? AND p.status = 'published' AND p.approval_status = 'approved'
+    ORDER BY p.pinned_at DESC, p.created_at DESC`
 
 	rows, err := DB.Query(query, groupID)
 	if err != nil {
@@ -284,7 +391,8 @@ func GetPostsByGroupID(groupID int) ([]Post, error) {
 
 	for rows.Next() {
 		var post Post
-		err := rows.Scan(&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.CreatedAt, &post.FullName)
+		dest := []interface{}{&post.PostID, &post.UserID, &post.GroupID, &post.Content, &post.PostImage, &post.CreatedAt, &post.FullName}
+		err := rows.Scan(append(dest, postDetailDest(&post)...)...)
 		if err != nil {
 			log.Printf("Error scanning row: %v", err)
 			continue
@@ -297,6 +405,10 @@ func GetPostsByGroupID(groupID int) ([]Post, error) {
 		return nil, err
 	}
 
+	if err := fillPostDetails(posts, viewerID); err != nil {
+		return nil, err
+	}
+
 	return posts, nil
 }
 
@@ -327,3 +439,162 @@ func GetViewerStatus(postID, userID int) (bool, error) {
 
 	return exists, nil
 }
+
+// CanViewPost reports whether the user is allowed to see the post
+func CanViewPost(postID, userID int) (bool, error) {
+	query := "SELECT EXISTS(SELECT 1 FROM posts p WHERE p.post_id = ? AND " + visiblePostCondition + ")"
+
+	args := append([]interface{}{postID}, visiblePostArgs(userID)...)
+
+	var visible bool
+	err := DB.QueryRow(query, args...).Scan(&visible)
+	if err != nil {
+		log.Printf("Error checking post visibility: %v", err)
+		return false, err
+	}
+
+	return visible, nil
+}
+
+// GetPostAuthor returns the author of a post, or ErrPostNotFound if there is no such post
+func GetPostAuthor(postID int) (int, error) {
+	var authorID int
+	err := DB.QueryRow("SELECT user_id FROM posts WHERE post_id = ?", postID).Scan(&authorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrPostNotFound
+		}
+		log.Printf("Error fetching post author: %v", err)
+		return 0, err
+	}
+
+	return authorID, nil
+}
+
+// fillPostDetails adds the viewer dependent details (likes, ...) to every post of a list.
+// The columns of postDetailColumns are expected to be scanned already.
+func fillPostDetails(posts []Post, viewerID int) error {
+	if err := markSavedPosts(posts, viewerID); err != nil {
+		return err
+	}
+
+	for i := range posts {
+		if err := fillPostDetail(&posts[i], viewerID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markSavedPosts sets SavedByMe on the posts of a list the viewer has bookmarked, with one query for the whole list
+func markSavedPosts(posts []Post, viewerID int) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	args := []interface{}{viewerID}
+	for _, post := range posts {
+		args = append(args, post.PostID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(posts)), ", ")
+
+	rows, err := DB.Query("SELECT DISTINCT post_id FROM bookmarks WHERE user_id = ? AND post_id IN ("+placeholders+")", args...)
+	if err != nil {
+		log.Printf("Error querying saved posts: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	saved := make(map[int]bool)
+	for rows.Next() {
+		var postID int
+		if err := rows.Scan(&postID); err != nil {
+			log.Printf("Error scanning saved post: %v", err)
+			return err
+		}
+		saved[postID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating over saved posts: %v", err)
+		return err
+	}
+
+	for i := range posts {
+		posts[i].SavedByMe = saved[posts[i].PostID]
+	}
+
+	return nil
+}
+
+func fillPostDetail(post *Post, viewerID int) error {
+	if err := attachPostLikes(post, viewerID); err != nil {
+		return err
+	}
+
+	if err := attachPostMedia(post); err != nil {
+		return err
+	}
+
+	if err := attachPoll(post, viewerID); err != nil {
+		return err
+	}
+
+	return attachResharedPost(post, viewerID)
+}
+
+// DeletePost removes a post together with its viewers, likes, comments, media, tags, bookmarks and poll.
+// Reshares of the post are kept and show the original as unavailable. It returns the URLs of the
+// images of the post and its comments, so the caller can remove the files.
+func DeletePost(postID int) ([]string, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+
+	fileURLs, err := deletePost(tx, postID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return fileURLs, nil
+}
+
+func deletePost(tx *sql.Tx, postID int) ([]string, error) {
+	// The file URLs are collected before their rows go
+	fileURLs, err := mediaURLs(tx, "post_id = ? OR comment_id IN (SELECT comment_id FROM comments WHERE post_id = ?)", postID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := []string{
+		"DELETE FROM post_viewers WHERE post_id = ?",
+		"DELETE FROM post_likes WHERE post_id = ?",
+		"DELETE FROM comment_likes WHERE comment_id IN (SELECT comment_id FROM comments WHERE post_id = ?)",
+		"DELETE FROM media WHERE comment_id IN (SELECT comment_id FROM comments WHERE post_id = ?)",
+		"DELETE FROM media WHERE post_id = ?",
+		"DELETE FROM comments WHERE post_id = ?",
+		"DELETE FROM post_tags WHERE post_id = ?",
+		"DELETE FROM bookmarks WHERE post_id = ?",
+		"DELETE FROM poll_votes WHERE post_id = ?",
+		"DELETE FROM poll_options WHERE post_id = ?",
+		"DELETE FROM polls WHERE post_id = ?",
+		"DELETE FROM posts WHERE post_id = ?",
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, postID); err != nil {
+			log.Printf("Error deleting post %d: %v", postID, err)
+			return nil, err
+		}
+	}
+
+	return fileURLs, nil
+}
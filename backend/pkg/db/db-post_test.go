@@ -0,0 +1,116 @@
+package db
+
+import "testing"
+
+func TestCanViewPost(t *testing.T) {
+	author := newTestUser(t, false)
+	follower := newTestUser(t, true)
+	member := newTestUser(t, true)
+	outsider := newTestUser(t, true)
+	addTestFollower(t, follower, author)
+
+	closedGroup, _ := newTestGroup(t, author, GroupClosed)
+	addTestMember(t, closedGroup, member, "accepted", GroupRoleMember)
+	publicGroup, _ := newTestGroup(t, author, GroupPublic)
+
+	publicPost := newTestPost(t, author, 0, "public")
+	privatePost := newTestPost(t, author, 0, "private")
+	closedGroupPost := newTestPost(t, author, closedGroup, "private")
+	publicGroupPost := newTestPost(t, author, publicGroup, "private")
+
+	pendingPost := newTestPost(t, author, publicGroup, "private")
+	if _, err := DB.Exec("UPDATE posts SET approval_status = 'pending' WHERE post_id = ?", pendingPost); err != nil {
+		t.Fatalf("marking post pending: %v", err)
+	}
+	draftPost := newTestPost(t, author, 0, "public")
+	if _, err := DB.Exec("UPDATE posts SET status = 'draft' WHERE post_id = ?", draftPost); err != nil {
+		t.Fatalf("marking post draft: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		postID int
+		userID int
+		want   bool
+	}{
+		{"public post, outsider", publicPost, outsider, true},
+		{"private post, follower", privatePost, follower, true},
+		{"private post, outsider", privatePost, outsider, false},
+		{"closed group post, member", closedGroupPost, member, true},
+		{"closed group post, follower of the author", closedGroupPost, follower, false},
+		{"closed group post, outsider", closedGroupPost, outsider, false},
+		{"public group post, outsider", publicGroupPost, outsider, true},
+		{"pending group post, outsider", pendingPost, outsider, false},
+		{"draft, outsider", draftPost, outsider, false},
+		{"missing post", -1, author, false},
+	}
+
+	for _, tt := range tests {
+		got, err := CanViewPost(tt.postID, tt.userID)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: CanViewPost = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetPostsForUserAppliesGroupVisibility(t *testing.T) {
+	author := newTestUser(t, false)
+	follower := newTestUser(t, true)
+	addTestFollower(t, follower, author)
+
+	closedGroup, _ := newTestGroup(t, author, GroupClosed)
+	privatePost := newTestPost(t, author, 0, "private")
+	closedGroupPost := newTestPost(t, author, closedGroup, "private")
+
+	posts, err := GetPostsForUser(author, follower)
+	if err != nil {
+		t.Fatalf("GetPostsForUser: %v", err)
+	}
+
+	seen := make(map[int]bool)
+	for _, post := range posts {
+		seen[post.PostID] = true
+	}
+	if !seen[privatePost] {
+		t.Errorf("follower doesn't see the author's private post")
+	}
+	if seen[closedGroupPost] {
+		t.Errorf("follower sees the author's post in a closed group they aren't a member of")
+	}
+}
+
+func TestDeletePostReturnsMediaURLs(t *testing.T) {
+	author := newTestUser(t, true)
+
+	privacyLevel := "public"
+	postID, err := InsertPost(Post{UserID: author, Content: "Post with images", PrivacyLevel: &privacyLevel,
+		Media: []Media{{URL: "http://localhost:8000/uploads/post-image/a.png"}, {URL: "http://localhost:8000/uploads/post-image/b.png"}}})
+	if err != nil {
+		t.Fatalf("inserting post: %v", err)
+	}
+
+	_, err = InsertComment(Comment{PostID: postID, UserID: author, Content: "Comment with an image",
+		Media: []Media{{URL: "http://localhost:8000/uploads/comment-image/c.png"}}})
+	if err != nil {
+		t.Fatalf("inserting comment: %v", err)
+	}
+
+	fileURLs, err := DeletePost(postID)
+	if err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if len(fileURLs) != 3 {
+		t.Fatalf("DeletePost returned %v, want the URLs of the two post images and the comment image", fileURLs)
+	}
+
+	var remaining int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM media WHERE url IN (?, ?, ?)", fileURLs[0], fileURLs[1], fileURLs[2]).Scan(&remaining); err != nil {
+		t.Fatalf("counting media: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("%d media rows left after deleting the post", remaining)
+	}
+}
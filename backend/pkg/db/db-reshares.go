@@ -0,0 +1,91 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPostNotFound            = errors.New("post not found")
+	ErrReshareGroupPost        = errors.New("group posts can't be reshared")
+	ErrReshareBroadensAudience = errors.New("a reshare can't be visible to a wider audience than the original post")
+)
+
+// privacyRanks orders the privacy levels from the widest audience to the narrowest
+var privacyRanks = map[string]int{
+	"public":  0,
+	"private": 1,
+	"friends": 2,
+}
+
+// ReshareTarget checks that the user may reshare the post with the given privacy level and returns
+// the ID of the post the reshare should point to. Reshares of reshares point to the original post,
+// and the reshare may not be visible to more people than any post in the chain.
+func ReshareTarget(postID, userID int, privacyLevel string) (int, error) {
+	targetID := postID
+
+	for {
+		visible, err := CanViewPost(targetID, userID)
+		if err != nil {
+			return 0, err
+		}
+		if !visible {
+			return 0, ErrPostNotFound
+		}
+
+		var groupID, resharedPostID *int
+		var targetPrivacy string
+		err = DB.QueryRow("SELECT group_id, privacy_level, reshared_post_id FROM posts WHERE post_id = ?", targetID).
+			Scan(&groupID, &targetPrivacy, &resharedPostID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, ErrPostNotFound
+			}
+			return 0, fmt.Errorf("ReshareTarget: failed to fetch post: %v", err)
+		}
+
+		if groupID != nil {
+			return 0, ErrReshareGroupPost
+		}
+
+		if privacyRanks[privacyLevel] < privacyRanks[targetPrivacy] {
+			return 0, ErrReshareBroadensAudience
+		}
+
+		if resharedPostID == nil {
+			return targetID, nil
+		}
+		targetID = *resharedPostID
+	}
+}
+
+// attachResharedPost loads the original of a reshare as the viewer sees it
+func attachResharedPost(post *Post, viewerID int) error {
+	if post.ResharedPostID == nil {
+		return nil
+	}
+
+	// CanViewPost is false as well when the original was deleted
+	visible, err := CanViewPost(*post.ResharedPostID, viewerID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		post.ReshareUnavailable = true
+		return nil
+	}
+
+	original, err := GetPostByPostID(*post.ResharedPostID, viewerID)
+	if err != nil {
+		return err
+	}
+	post.ResharedPost = &original
+
+	return nil
+}
+
+func ValidPrivacyLevel(privacyLevel string) bool {
+	_, ok := privacyRanks[privacyLevel]
+	return ok
+}
@@ -0,0 +1,272 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Publishing states of a post
+const (
+	PostPublished = "published"
+	PostDraft     = "draft"
+	PostScheduled = "scheduled"
+)
+
+// sqliteTimestampLayout is the format of CURRENT_TIMESTAMP, times stored in it compare with it as text
+const sqliteTimestampLayout = "2006-01-02 15:04:05"
+
+type ScheduledPostUpdate struct {
+	Content   string     `json:"content"`
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// postAuthorInGroupCondition holds for posts of the alias p outside groups, and for group posts whose
+// author is still an accepted member of the group and not banned from it
+const postAuthorInGroupCondition = `(p.group_id IS NULL OR (
+    EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = p.group_id AND gm.user_id = p.user_id AND gm.status = 'accepted')
+    AND NOT EXISTS (SELECT 1 FROM group_bans gb WHERE gb.group_id = p.group_id AND gb.user_id = p.user_id)))`
+
+// formatTimestamp formats a time for storing in a TIMESTAMP column, nil stays NULL
+func formatTimestamp(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	formatted := t.UTC().Format(sqliteTimestampLayout)
+	return &formatted
+}
+
+// ValidatePublishing checks the publishing state of a new or edited post and returns it normalized.
+// An empty status means the post is published right away. Only scheduled posts keep their publishing time.
+func ValidatePublishing(status string, publishAt *time.Time) (string, *time.Time, error) {
+	switch status {
+	case "", PostPublished:
+		return PostPublished, nil, nil
+	case PostDraft:
+		return PostDraft, nil, nil
+	case PostScheduled:
+		if publishAt == nil || !publishAt.After(time.Now()) {
+			return "", nil, errors.New("scheduled posts need a publishing time in the future")
+		}
+		return PostScheduled, publishAt, nil
+	default:
+		return "", nil, errors.New("invalid post status")
+	}
+}
+
+// GetScheduledPosts returns the user's drafts and scheduled posts, including group posts,
+// the ones published next first
+func GetScheduledPosts(userID int) ([]Post, error) {
+	query := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at,
+                     (u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
+              FROM posts p
+              JOIN users u ON p.user_id = u.user_id
+              WHERE p.user_id = ? AND p.status IN ('draft', 'scheduled')
+              ORDER BY p.publish_at IS NULL, p.publish_at, p.created_at DESC`
+
+	posts, err := fetchPosts(query, userID)
+	if err != nil {
+		log.Printf("Error querying scheduled posts: %v", err)
+		return nil, err
+	}
+
+	if err := fillPostDetails(posts, userID); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// GetUnpublishedPostAuthor returns the author of a draft or scheduled post, or ErrPostNotFound
+// if there is no such post
+func GetUnpublishedPostAuthor(postID int) (int, error) {
+	var authorID int
+	err := DB.QueryRow("SELECT user_id FROM posts WHERE post_id = ? AND status != 'published'", postID).Scan(&authorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrPostNotFound
+		}
+		return 0, fmt.Errorf("GetUnpublishedPostAuthor: failed to fetch post: %v", err)
+	}
+
+	return authorID, nil
+}
+
+// UpdateScheduledPost changes the content and publishing state of a draft or scheduled post.
+// Group posts whose content changes go back to the approval queue if the group reviews posts, and
+// the posts of authors no longer in the group can't be changed, see PublishPost. It returns true if the post went online by the update, see PublishPost.
+func UpdateScheduledPost(postID int, update ScheduledPostUpdate) (bool, error) {
+	// A post published by the update is stored as a draft first, PublishPost then publishes and dates it
+	status := update.Status
+	if status == PostPublished {
+		status = PostDraft
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return false, err
+	}
+
+	var authorID int
+	var groupID sql.NullInt64
+	var content string
+	var inGroup bool
+	err = tx.QueryRow("SELECT p.user_id, p.group_id, p.content, "+postAuthorInGroupCondition+" FROM posts p WHERE p.post_id = ?",
+		postID).Scan(&authorID, &groupID, &content, &inGroup)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return false, fmt.Errorf("UpdateScheduledPost: failed to fetch post: %v", err)
+	}
+	if !inGroup {
+		tx.Rollback()
+		return false, ErrNotGroupMember
+	}
+
+	_, err = tx.Exec("UPDATE posts SET content = ?, status = ?, publish_at = ? WHERE post_id = ? AND status != 'published'",
+		update.Content, status, formatTimestamp(update.PublishAt), postID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error updating scheduled post: %v", err)
+		return false, err
+	}
+
+	// Changed content of a group post is reviewed again, an earlier approval was for the old content
+	if groupID.Valid && update.Content != content {
+		var needsApproval bool
+		err = tx.QueryRow(groupPostNeedsApprovalQuery, authorID, groupID.Int64).Scan(&needsApproval)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			return false, fmt.Errorf("UpdateScheduledPost: failed to check group settings: %v", err)
+		}
+
+		if needsApproval {
+			_, err = tx.Exec("UPDATE posts SET approval_status = 'pending', reviewed_by = NULL, reviewed_at = NULL WHERE post_id = ?", postID)
+			if err != nil {
+				tx.Rollback() // Roll back in case of error
+				log.Printf("Error resetting post approval: %v", err)
+				return false, err
+			}
+		}
+	}
+
+	// The tags are indexed again for the new content
+	_, err = tx.Exec("DELETE FROM post_tags WHERE post_id = ?", postID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting post tags: %v", err)
+		return false, err
+	}
+
+	for _, tag := range ExtractHashtags(update.Content) {
+		_, err = tx.Exec("INSERT OR IGNORE INTO post_tags (post_id, tag) VALUES (?, ?)", postID, tag)
+		if err != nil {
+			tx.Rollback() // Roll back in case of error
+			log.Printf("Error inserting post tag: %v", err)
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	if update.Status == PostPublished {
+		return PublishPost(postID)
+	}
+
+	return false, nil
+}
+
+// PublishPost publishes a draft or scheduled post. It returns false if the post was already
+// published, so notifications are only sent once, and for group posts waiting for approval,
+// whose notifications are sent when they are approved. Group posts of authors who left the group
+// or were removed or banned from it aren't published, ErrNotGroupMember is returned for them.
+func PublishPost(postID int) (bool, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return false, err
+	}
+
+	var inGroup bool
+	err = tx.QueryRow("SELECT "+postAuthorInGroupCondition+" FROM posts p WHERE p.post_id = ?", postID).Scan(&inGroup)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback() // Roll back in case of error
+		return false, fmt.Errorf("PublishPost: failed to check group membership: %v", err)
+	}
+	if err == nil && !inGroup {
+		tx.Rollback()
+		return false, ErrNotGroupMember
+	}
+
+	// The post is dated at its publication, so it shows up as new in the feeds
+	result, err := tx.Exec(`UPDATE posts SET status = 'published', publish_at = NULL, created_at = CURRENT_TIMESTAMP
+	                        WHERE post_id = ? AND status != 'published'`, postID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error publishing post: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return false, err
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	_, err = tx.Exec("UPDATE post_tags SET created_at = CURRENT_TIMESTAMP WHERE post_id = ?", postID)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error updating post tags: %v", err)
+		return false, err
+	}
+
+	var approvalStatus string
+	err = tx.QueryRow("SELECT approval_status FROM posts WHERE post_id = ?", postID).Scan(&approvalStatus)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		return false, fmt.Errorf("PublishPost: failed to fetch approval status: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return approvalStatus == ApprovalApproved, nil
+}
+
+// GetDuePostIDs returns the scheduled posts whose publishing time has come. Group posts whose
+// author is no longer a member are left out, PublishPost wouldn't publish them.
+func GetDuePostIDs() ([]int, error) {
+	rows, err := DB.Query(`SELECT p.post_id FROM posts p
+	                       WHERE p.status = 'scheduled' AND p.publish_at <= CURRENT_TIMESTAMP AND ` + postAuthorInGroupCondition + `
+	                       ORDER BY p.publish_at`)
+	if err != nil {
+		return nil, fmt.Errorf("GetDuePostIDs: failed to query posts: %v", err)
+	}
+	defer rows.Close()
+
+	var postIDs []int
+	for rows.Next() {
+		var postID int
+		if err := rows.Scan(&postID); err != nil {
+			return nil, fmt.Errorf("GetDuePostIDs: failed to scan post row: %v", err)
+		}
+		postIDs = append(postIDs, postID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetDuePostIDs: error iterating over post rows: %v", err)
+	}
+
+	return postIDs, nil
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestScheduledGroupPost inserts a group post scheduled for tomorrow
+func newTestScheduledGroupPost(t *testing.T, userID, groupID int, approvalStatus string) int {
+	t.Helper()
+
+	publishAt := time.Now().Add(24 * time.Hour)
+	postID, err := InsertGroupPost(GroupPost{UserID: userID, GroupID: groupID, Content: "Scheduled post",
+		Status: PostScheduled, PublishAt: &publishAt, ApprovalStatus: approvalStatus})
+	if err != nil {
+		t.Fatalf("inserting group post: %v", err)
+	}
+
+	return postID
+}
+
+func TestUpdateScheduledPostResetsApproval(t *testing.T) {
+	owner := newTestUser(t, true)
+	member := newTestUser(t, true)
+
+	groupID, _ := newTestGroup(t, owner, GroupPublic)
+	addTestMember(t, groupID, member, "accepted", GroupRoleMember)
+	if err := SetGroupPostApproval(groupID, true); err != nil {
+		t.Fatalf("SetGroupPostApproval: %v", err)
+	}
+
+	postID := newTestScheduledGroupPost(t, member, groupID, ApprovalPending)
+	if _, err := ReviewGroupPost(groupID, postID, owner, true); err != nil {
+		t.Fatalf("ReviewGroupPost: %v", err)
+	}
+
+	publishAt := time.Now().Add(48 * time.Hour)
+	approvalAfter := func(content string) (string, bool) {
+		t.Helper()
+
+		update := ScheduledPostUpdate{Content: content, Status: PostScheduled, PublishAt: &publishAt}
+		if _, err := UpdateScheduledPost(postID, update); err != nil {
+			t.Fatalf("UpdateScheduledPost: %v", err)
+		}
+
+		var status string
+		var reviewed bool
+		err := DB.QueryRow("SELECT approval_status, reviewed_by IS NOT NULL FROM posts WHERE post_id = ?", postID).Scan(&status, &reviewed)
+		if err != nil {
+			t.Fatalf("fetching approval status: %v", err)
+		}
+		return status, reviewed
+	}
+
+	if status, reviewed := approvalAfter("Scheduled post"); status != ApprovalApproved || !reviewed {
+		t.Errorf("unchanged content: approval_status = %s, reviewed = %v, want approved and reviewed", status, reviewed)
+	}
+	if status, reviewed := approvalAfter("Edited after the review"); status != ApprovalPending || reviewed {
+		t.Errorf("changed content: approval_status = %s, reviewed = %v, want pending and not reviewed", status, reviewed)
+	}
+}
+
+func TestScheduledGroupPostsOfFormerMembers(t *testing.T) {
+	owner := newTestUser(t, true)
+	leaver := newTestUser(t, true)
+	removed := newTestUser(t, true)
+
+	groupID, _ := newTestGroup(t, owner, GroupPublic)
+	addTestMember(t, groupID, leaver, "accepted", GroupRoleMember)
+	addTestMember(t, groupID, removed, "accepted", GroupRoleMember)
+
+	leaverPost := newTestScheduledGroupPost(t, leaver, groupID, ApprovalApproved)
+	removedPost := newTestScheduledGroupPost(t, removed, groupID, ApprovalApproved)
+	ownerPost := newTestScheduledGroupPost(t, owner, groupID, ApprovalApproved)
+	if _, err := DB.Exec("UPDATE posts SET publish_at = '2000-01-01 00:00:00' WHERE post_id IN (?, ?)", leaverPost, ownerPost); err != nil {
+		t.Fatalf("making posts due: %v", err)
+	}
+
+	if err := LeaveGroup(leaver, groupID); err != nil {
+		t.Fatalf("LeaveGroup: %v", err)
+	}
+	if _, err := RemoveGroupMember(groupID, owner, removed, "", true); err != nil {
+		t.Fatalf("RemoveGroupMember: %v", err)
+	}
+
+	duePostIDs, err := GetDuePostIDs()
+	if err != nil {
+		t.Fatalf("GetDuePostIDs: %v", err)
+	}
+	due := make(map[int]bool)
+	for _, postID := range duePostIDs {
+		due[postID] = true
+	}
+	if !due[ownerPost] || due[leaverPost] {
+		t.Errorf("GetDuePostIDs = %v, want the owner's post %d but not the former member's post %d", duePostIDs, ownerPost, leaverPost)
+	}
+
+	if _, err := PublishPost(leaverPost); err != ErrNotGroupMember {
+		t.Errorf("PublishPost of a former member: err = %v, want ErrNotGroupMember", err)
+	}
+	if _, err := UpdateScheduledPost(leaverPost, ScheduledPostUpdate{Content: "Published anyway", Status: PostPublished}); err != ErrNotGroupMember {
+		t.Errorf("UpdateScheduledPost of a former member: err = %v, want ErrNotGroupMember", err)
+	}
+
+	if _, err := GetUnpublishedPostAuthor(removedPost); err != ErrPostNotFound {
+		t.Errorf("scheduled post of a banned member: err = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestPendingGroupPostsLeaveOutDrafts(t *testing.T) {
+	owner := newTestUser(t, true)
+	member := newTestUser(t, true)
+
+	groupID, _ := newTestGroup(t, owner, GroupPublic)
+	addTestMember(t, groupID, member, "accepted", GroupRoleMember)
+
+	draftID, err := InsertGroupPost(GroupPost{UserID: member, GroupID: groupID, Content: "Draft", Status: PostDraft, ApprovalStatus: ApprovalPending})
+	if err != nil {
+		t.Fatalf("inserting group post: %v", err)
+	}
+	scheduledID := newTestScheduledGroupPost(t, member, groupID, ApprovalPending)
+
+	posts, err := GetPendingGroupPosts(groupID, owner, 10, 0)
+	if err != nil {
+		t.Fatalf("GetPendingGroupPosts: %v", err)
+	}
+	if len(posts) != 1 || posts[0].PostID != scheduledID {
+		t.Errorf("GetPendingGroupPosts returned %d posts, want only the scheduled post %d", len(posts), scheduledID)
+	}
+
+	if _, err := ReviewGroupPost(groupID, draftID, owner, true); err != ErrPostNotPending {
+		t.Errorf("ReviewGroupPost of a draft: err = %v, want ErrPostNotPending", err)
+	}
+}
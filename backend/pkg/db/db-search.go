@@ -0,0 +1,206 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Result types the search can be narrowed to
+const (
+	SearchTypeUsers  = "users"
+	SearchTypePosts  = "posts"
+	SearchTypeGroups = "groups"
+	SearchTypeEvents = "events"
+)
+
+var SearchTypes = []string{SearchTypeUsers, SearchTypePosts, SearchTypeGroups, SearchTypeEvents}
+
+type SearchUser struct {
+	UserID   int    `json:"user_id"`
+	FullName string `json:"full_name"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+type SearchResults struct {
+	Users  []SearchUser `json:"users"`
+	Posts  []Post       `json:"posts"`
+	Groups []Group      `json:"groups"`
+	Events []Event      `json:"events"`
+}
+
+// BuildSearchQuery turns free text into an FTS5 query matching rows that contain every word,
+// each as a prefix. Words are quoted so FTS5 operators in the input are matched literally.
+func BuildSearchQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, `"`+word+`"*`)
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// SearchUsers returns one page of the users whose name or nickname matches, best match first
+func SearchUsers(match string, limit, offset int) ([]SearchUser, error) {
+	query := `SELECT u.user_id, u.firstname || ' ' || u.lastname, COALESCE(u.nickname, ''), COALESCE(u.avatar, '')
+              FROM users_fts
+              JOIN users u ON u.user_id = users_fts.rowid
+              WHERE users_fts MATCH ?
+              ORDER BY users_fts.rank
+              LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, match, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("SearchUsers: failed to query users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []SearchUser{}
+	for rows.Next() {
+		var user SearchUser
+		if err := rows.Scan(&user.UserID, &user.FullName, &user.Nickname, &user.Avatar); err != nil {
+			return nil, fmt.Errorf("SearchUsers: failed to scan user row: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("SearchUsers: error iterating over user rows: %v", err)
+	}
+
+	return users, nil
+}
+
+// SearchPosts returns one page of the matching posts the viewer is allowed to see, best match first
+func SearchPosts(match string, viewerID, limit, offset int) ([]Post, error) {
+	query := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at,
+                     (u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
+              FROM posts_fts
+              JOIN posts p ON p.post_id = posts_fts.rowid
+              JOIN users u ON p.user_id = u.user_id
+              WHERE posts_fts MATCH ? AND ` + visiblePostCondition + `
+              ORDER BY posts_fts.rank
+              LIMIT ? OFFSET ?`
+
+	args := append([]interface{}{match}, visiblePostArgs(viewerID)...)
+	args = append(args, limit, offset)
+
+	posts, err := fetchPosts(query, args...)
+	if err != nil {
+		log.Printf("Error searching posts: %v", err)
+		return nil, err
+	}
+
+	if err := fillPostDetails(posts, viewerID); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// SearchGroups returns one page of the groups whose title or description matches, best match first.
+// Secret groups only show up for their members and invited users.
+func SearchGroups(match string, viewerID, limit, offset int) ([]Group, error) {
+	query := `SELECT g.group_id, g.user_id, g.title, g.content, g.visibility, g.cover_image, u.firstname, u.lastname, g.created_at
+              FROM groups_fts
+              JOIN groups g ON g.group_id = groups_fts.rowid
+              INNER JOIN users u ON g.user_id = u.user_id
+              WHERE groups_fts MATCH ? AND ` + groupListedCondition + `
+              ORDER BY groups_fts.rank
+              LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, match, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("SearchGroups: failed to query groups: %v", err)
+	}
+	defer rows.Close()
+
+	groups := []Group{}
+	for rows.Next() {
+		var group Group
+		err := rows.Scan(&group.GroupID, &group.UserID, &group.Title, &group.Content, &group.Visibility, &group.CoverImage, &group.CreatorFirstname, &group.CreatorLastname, &group.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("SearchGroups: failed to scan group row: %v", err)
+		}
+		group.CreatorName = fmt.Sprintf("%s %s", group.CreatorFirstname, group.CreatorLastname)
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("SearchGroups: error iterating over group rows: %v", err)
+	}
+
+	return groups, nil
+}
+
+// SearchEvents returns one page of the matching events of the groups the viewer may read, that is
+// public groups and those the viewer is a member of, best match first
+func SearchEvents(match string, viewerID, limit, offset int) ([]Event, error) {
+	query := `SELECT e.event_id, e.user_id, e.group_id, e.date, e.title, e.content,
+                     u.firstname || ' ' || u.lastname AS creator_name, e.created_at
+              FROM events_fts
+              JOIN events e ON e.event_id = events_fts.rowid
+              INNER JOIN users u ON e.user_id = u.user_id
+              JOIN groups g ON e.group_id = g.group_id
+              WHERE events_fts MATCH ?
+                AND ` + groupReadableCondition + `
+              ORDER BY events_fts.rank
+              LIMIT ? OFFSET ?`
+
+	rows, err := DB.Query(query, match, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("SearchEvents: failed to query events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		err := rows.Scan(&event.EventID, &event.UserID, &event.GroupID, &event.Date, &event.Title, &event.Content, &event.CreatorName, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("SearchEvents: failed to scan event row: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("SearchEvents: error iterating over event rows: %v", err)
+	}
+
+	return events, nil
+}
+
+// Search runs the text search over the requested result types. Types that were not requested
+// are left nil.
+func Search(text string, types []string, viewerID, limit, offset int) (SearchResults, error) {
+	var results SearchResults
+
+	match := BuildSearchQuery(text)
+	if match == "" {
+		return results, nil
+	}
+
+	var err error
+	for _, searchType := range types {
+		switch searchType {
+		case SearchTypeUsers:
+			results.Users, err = SearchUsers(match, limit, offset)
+		case SearchTypePosts:
+			results.Posts, err = SearchPosts(match, viewerID, limit, offset)
+		case SearchTypeGroups:
+			results.Groups, err = SearchGroups(match, viewerID, limit, offset)
+		case SearchTypeEvents:
+			results.Events, err = SearchEvents(match, viewerID, limit, offset)
+		}
+		if err != nil {
+			return SearchResults{}, err
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import "testing"
+
+func TestSearchEventsFollowsGroupReadability(t *testing.T) {
+	owner := newTestUser(t, true)
+	member := newTestUser(t, true)
+	outsider := newTestUser(t, true)
+
+	titles := map[string]string{GroupPublic: "Picnicpublic", GroupClosed: "Picnicclosed", GroupSecret: "Picnicsecret"}
+	for visibility, title := range titles {
+		groupID, _ := newTestGroup(t, owner, visibility)
+		addTestMember(t, groupID, member, "accepted", GroupRoleMember)
+
+		err := InsertEvent(Event{UserID: owner, GroupID: groupID, Date: "2030-01-01", Title: title, Content: "Bring food"})
+		if err != nil {
+			t.Fatalf("inserting event: %v", err)
+		}
+	}
+
+	for visibility, title := range titles {
+		tests := []struct {
+			name   string
+			userID int
+			want   bool
+		}{
+			{"member", member, true},
+			{"outsider", outsider, visibility == GroupPublic},
+		}
+
+		for _, tt := range tests {
+			events, err := SearchEvents(BuildSearchQuery(title), tt.userID, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchEvents: %v", err)
+			}
+			if found := len(events) == 1; found != tt.want {
+				t.Errorf("%s group, %s: event found = %v, want %v", visibility, tt.name, found, tt.want)
+			}
+		}
+	}
+}
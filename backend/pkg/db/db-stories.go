@@ -0,0 +1,308 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	StoryLifetime         = 24 * time.Hour
+	maxStoryContentLength = 500
+)
+
+var ErrStoryNotFound = errors.New("story not found")
+
+// Story is a short lived post with text and at most one image. When creating a story only
+// Content and Media are read, Media.Data holds the image to upload.
+type Story struct {
+	StoryID    int       `json:"story_id"`
+	UserID     int       `json:"user_id"`
+	FullName   string    `json:"full_name"`
+	Avatar     string    `json:"avatar"`
+	Content    string    `json:"content"`
+	Media      *Media    `json:"media,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	ViewedByMe bool      `json:"viewed_by_me"`
+	// ViewCount is only filled in for the author
+	ViewCount *int `json:"view_count,omitempty"`
+}
+
+type StoryViewer struct {
+	UserID   int       `json:"user_id"`
+	FullName string    `json:"full_name"`
+	Avatar   string    `json:"avatar"`
+	ViewedAt time.Time `json:"viewed_at"`
+}
+
+// storyVisibleCondition matches active stories (aliased s, authors aliased u) the viewer may see:
+// their own, those of public profiles and those of users they follow.
+// It expects the viewer's user ID bound twice.
+const storyVisibleCondition = `(s.expires_at > CURRENT_TIMESTAMP AND (s.user_id = ?
+	OR u.profile_public = 1
+	OR EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = s.user_id AND f.status = 'accepted')))`
+
+// ValidateStory trims the content of a new story and checks that it has text or an image
+func ValidateStory(story *Story) error {
+	story.Content = strings.TrimSpace(story.Content)
+	if len(story.Content) > maxStoryContentLength {
+		return fmt.Errorf("stories can be at most %d characters", maxStoryContentLength)
+	}
+
+	if story.Media != nil {
+		if err := ValidateMedia([]Media{*story.Media}, 1); err != nil {
+			return err
+		}
+		story.Media.AltText = strings.TrimSpace(story.Media.AltText)
+	}
+
+	if story.Content == "" && story.Media == nil {
+		return errors.New("a story needs text or an image")
+	}
+
+	return nil
+}
+
+// InsertStory stores a new story, which expires StoryLifetime after now
+func InsertStory(story Story) (int, error) {
+	var imageURL *string
+	var altText string
+	var width, height *int
+	if story.Media != nil {
+		imageURL = &story.Media.URL
+		altText = story.Media.AltText
+		width = story.Media.Width
+		height = story.Media.Height
+	}
+
+	expiresAt := time.Now().Add(StoryLifetime)
+
+	result, err := DB.Exec(`INSERT INTO stories (user_id, content, image_url, alt_text, width, height, expires_at)
+	                        VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		story.UserID, story.Content, imageURL, altText, width, height, formatTimestamp(&expiresAt))
+	if err != nil {
+		log.Printf("Error inserting story: %v", err)
+		return 0, err
+	}
+
+	storyID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(storyID), nil
+}
+
+// GetStoriesFeed returns the active stories the viewer may see, the viewer's own first,
+// then grouped by author with the most recently active authors first
+func GetStoriesFeed(viewerID int) ([]Story, error) {
+	query := `SELECT ` + storyColumns + `
+	          FROM stories s
+	          JOIN users u ON s.user_id = u.user_id
+	          WHERE ` + storyVisibleCondition + `
+	          ORDER BY s.user_id = ? DESC,
+	                   (SELECT MAX(s2.created_at) FROM stories s2 WHERE s2.user_id = s.user_id) DESC,
+	                   s.user_id, s.created_at`
+
+	return fetchStories(query, viewerID, viewerID, viewerID, viewerID, viewerID)
+}
+
+// GetUserStories returns the active stories of a user the viewer may see, oldest first
+func GetUserStories(userID, viewerID int) ([]Story, error) {
+	query := `SELECT ` + storyColumns + `
+	          FROM stories s
+	          JOIN users u ON s.user_id = u.user_id
+	          WHERE s.user_id = ? AND ` + storyVisibleCondition + `
+	          ORDER BY s.created_at`
+
+	return fetchStories(query, viewerID, viewerID, userID, viewerID, viewerID)
+}
+
+// storyColumns are read by fetchStories, they expect the viewer's user ID bound twice
+const storyColumns = `s.story_id, s.user_id, (u.firstname || ' ' || u.lastname), COALESCE(u.avatar, ''),
+	                 s.content, s.image_url, s.alt_text, s.width, s.height, s.created_at, s.expires_at,
+	                 EXISTS (SELECT 1 FROM story_views v WHERE v.story_id = s.story_id AND v.viewer_id = ?),
+	                 CASE WHEN s.user_id = ? THEN (SELECT COUNT(*) FROM story_views v WHERE v.story_id = s.story_id) END`
+
+func fetchStories(query string, args ...interface{}) ([]Story, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fetchStories: failed to query stories: %v", err)
+	}
+	defer rows.Close()
+
+	stories := []Story{}
+	for rows.Next() {
+		var story Story
+		var imageURL sql.NullString
+		var media Media
+		err := rows.Scan(&story.StoryID, &story.UserID, &story.FullName, &story.Avatar,
+			&story.Content, &imageURL, &media.AltText, &media.Width, &media.Height, &story.CreatedAt, &story.ExpiresAt,
+			&story.ViewedByMe, &story.ViewCount)
+		if err != nil {
+			return nil, fmt.Errorf("fetchStories: failed to scan story row: %v", err)
+		}
+
+		if imageURL.Valid {
+			media.URL = imageURL.String
+			story.Media = &media
+		}
+		stories = append(stories, story)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetchStories: error iterating over story rows: %v", err)
+	}
+
+	return stories, nil
+}
+
+// CanViewStory reports whether the story is active and visible to the viewer
+func CanViewStory(storyID, viewerID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM stories s JOIN users u ON s.user_id = u.user_id
+	                         WHERE s.story_id = ? AND ` + storyVisibleCondition + `)`
+
+	var visible bool
+	err := DB.QueryRow(query, storyID, viewerID, viewerID).Scan(&visible)
+	if err != nil {
+		return false, fmt.Errorf("CanViewStory: failed to check story visibility: %v", err)
+	}
+
+	return visible, nil
+}
+
+// GetStoryAuthor returns the author of an active story, or ErrStoryNotFound if there is no such story
+func GetStoryAuthor(storyID int) (int, error) {
+	var authorID int
+	err := DB.QueryRow("SELECT user_id FROM stories WHERE story_id = ? AND expires_at > CURRENT_TIMESTAMP", storyID).Scan(&authorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrStoryNotFound
+		}
+		return 0, fmt.Errorf("GetStoryAuthor: failed to fetch story: %v", err)
+	}
+
+	return authorID, nil
+}
+
+// MarkStoryViewed records that the viewer has seen the story, the author's own views are not counted
+func MarkStoryViewed(storyID, viewerID int) error {
+	_, err := DB.Exec(`INSERT OR IGNORE INTO story_views (story_id, viewer_id)
+	                   SELECT story_id, ? FROM stories WHERE story_id = ? AND user_id != ?`, viewerID, storyID, viewerID)
+	if err != nil {
+		log.Printf("Error inserting story view: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetStoryViewers lists who has seen a story, the latest viewers first
+func GetStoryViewers(storyID int) ([]StoryViewer, error) {
+	query := `SELECT u.user_id, (u.firstname || ' ' || u.lastname), COALESCE(u.avatar, ''), v.viewed_at
+	          FROM story_views v
+	          JOIN users u ON v.viewer_id = u.user_id
+	          WHERE v.story_id = ?
+	          ORDER BY v.viewed_at DESC`
+
+	rows, err := DB.Query(query, storyID)
+	if err != nil {
+		return nil, fmt.Errorf("GetStoryViewers: failed to query viewers: %v", err)
+	}
+	defer rows.Close()
+
+	viewers := []StoryViewer{}
+	for rows.Next() {
+		var viewer StoryViewer
+		if err := rows.Scan(&viewer.UserID, &viewer.FullName, &viewer.Avatar, &viewer.ViewedAt); err != nil {
+			return nil, fmt.Errorf("GetStoryViewers: failed to scan viewer row: %v", err)
+		}
+		viewers = append(viewers, viewer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetStoryViewers: error iterating over viewer rows: %v", err)
+	}
+
+	return viewers, nil
+}
+
+// DeleteStory removes a story and its views. It returns the URL of the story image, if any,
+// so the caller can remove the file.
+func DeleteStory(storyID int) (*string, error) {
+	var imageURL *string
+	err := DB.QueryRow("SELECT image_url FROM stories WHERE story_id = ?", storyID).Scan(&imageURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStoryNotFound
+		}
+		return nil, fmt.Errorf("DeleteStory: failed to fetch story: %v", err)
+	}
+
+	if err := deleteStories("story_id = ?", storyID); err != nil {
+		return nil, err
+	}
+
+	return imageURL, nil
+}
+
+// DeleteExpiredStories removes the expired stories and their views. It returns the URLs
+// of their images so the caller can remove the files.
+func DeleteExpiredStories() ([]string, error) {
+	// The cutoff is fixed first, so stories expiring meanwhile are left for the next run with their images
+	cutoff := time.Now().UTC().Format(sqliteTimestampLayout)
+
+	rows, err := DB.Query("SELECT image_url FROM stories WHERE expires_at <= ? AND image_url IS NOT NULL", cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("DeleteExpiredStories: failed to query stories: %v", err)
+	}
+	defer rows.Close()
+
+	var imageURLs []string
+	for rows.Next() {
+		var imageURL string
+		if err := rows.Scan(&imageURL); err != nil {
+			return nil, fmt.Errorf("DeleteExpiredStories: failed to scan story row: %v", err)
+		}
+		imageURLs = append(imageURLs, imageURL)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("DeleteExpiredStories: error iterating over story rows: %v", err)
+	}
+
+	if err := deleteStories("expires_at <= ?", cutoff); err != nil {
+		return nil, err
+	}
+
+	return imageURLs, nil
+}
+
+// deleteStories removes the stories matching the condition together with their views
+func deleteStories(condition string, args ...interface{}) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM story_views WHERE story_id IN (SELECT story_id FROM stories WHERE "+condition+")", args...)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting story views: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM stories WHERE "+condition, args...)
+	if err != nil {
+		tx.Rollback() // Roll back in case of error
+		log.Printf("Error deleting stories: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
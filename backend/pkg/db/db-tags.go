@@ -0,0 +1,116 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// hashtagPattern matches #tag at the start of the text or after a non-word character
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\w#&])#(\w+)`)
+
+// maxTagLength keeps accidental walls of text out of the tag index
+const maxTagLength = 100
+
+type TagCount struct {
+	Tag       string `json:"tag"`
+	PostCount int    `json:"post_count"`
+}
+
+// ExtractHashtags returns the distinct hashtags of a text, lowercased and without the leading #
+func ExtractHashtags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := NormalizeTag(match[1])
+		if tag == "" || len(tag) > maxTagLength || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// NormalizeTag turns user input like "#GoLang" into the form stored in the index
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// indexPostTags stores the hashtags of a post's content in the tags index
+func indexPostTags(postID int, content string) error {
+	for _, tag := range ExtractHashtags(content) {
+		_, err := DB.Exec("INSERT OR IGNORE INTO post_tags (post_id, tag) VALUES (?, ?)", postID, tag)
+		if err != nil {
+			log.Printf("Error inserting post tag: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetPostsForTag returns one page of the posts with a hashtag that the viewer is allowed to see, newest first
+func GetPostsForTag(tag string, viewerID, limit, offset int) ([]Post, error) {
+	query := `SELECT p.post_id, p.user_id, p.group_id, p.content, p.post_image, p.privacy_level, p.created_at,
+                     (u.firstname || ' ' || u.lastname) AS full_name, ` + postDetailColumns + `
+              FROM post_tags pt
+              JOIN posts p ON pt.post_id = p.post_id
+              JOIN users u ON p.user_id = u.user_id
+              WHERE pt.tag = ? AND ` + visiblePostCondition + `
+              ORDER BY p.created_at DESC, p.post_id DESC
+              LIMIT ? OFFSET ?`
+
+	args := append([]interface{}{NormalizeTag(tag)}, visiblePostArgs(viewerID)...)
+	args = append(args, limit, offset)
+
+	posts, err := fetchPosts(query, args...)
+	if err != nil {
+		log.Printf("Error querying posts for tag: %v", err)
+		return nil, err
+	}
+
+	if err := fillPostDetails(posts, viewerID); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// GetTrendingTags returns the tags used the most in the last hours, counting only posts the viewer can see
+func GetTrendingTags(viewerID, hours, limit int) ([]TagCount, error) {
+	query := `SELECT pt.tag, COUNT(*) AS post_count
+              FROM post_tags pt
+              JOIN posts p ON pt.post_id = p.post_id
+              WHERE pt.created_at >= datetime('now', ?) AND ` + visiblePostCondition + `
+              GROUP BY pt.tag
+              ORDER BY post_count DESC, MAX(pt.created_at) DESC
+              LIMIT ?`
+
+	args := append([]interface{}{fmt.Sprintf("-%d hours", hours)}, visiblePostArgs(viewerID)...)
+	args = append(args, limit)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetTrendingTags: failed to query tags: %v", err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Tag, &tag.PostCount); err != nil {
+			return nil, fmt.Errorf("GetTrendingTags: failed to scan tag row: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetTrendingTags: error iterating over tag rows: %v", err)
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the tests against a fresh database with all migrations applied. The search
+// migrations need FTS5, so the tests are run with the sqlite_fts5 tag like the server.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "backend-db-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dbPath := filepath.Join(dir, "test.db")
+	InitDB(dbPath)
+
+	if err := migrateDB("sqlite3", dbPath, "migrations/sqlite"); err != nil {
+		log.Fatal("Error applying migrations:", err)
+	}
+
+	code := m.Run()
+
+	CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newTestUser(t *testing.T, profilePublic bool) int {
+	t.Helper()
+
+	result, err := DB.Exec(`INSERT INTO users (email, password, firstname, lastname, date_of_birth, profile_public)
+	                        VALUES ('test@example.com', 'password', 'Test', 'User', '2000-01-01', ?)`, profilePublic)
+	if err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+
+	return lastInsertID(t, result.LastInsertId)
+}
+
+// newTestGroup creates a group owned by the user, together with its group chat
+func newTestGroup(t *testing.T, ownerID int, visibility string) (groupID, chatID int) {
+	t.Helper()
+
+	result, err := DB.Exec("INSERT INTO groups (user_id, title, content, visibility) VALUES (?, 'Test group', 'About the group', ?)",
+		ownerID, visibility)
+	if err != nil {
+		t.Fatalf("inserting group: %v", err)
+	}
+	groupID = lastInsertID(t, result.LastInsertId)
+
+	addTestMember(t, groupID, ownerID, "accepted", GroupRoleOwner)
+
+	result, err = DB.Exec("INSERT INTO chats (group_id) VALUES (?)", groupID)
+	if err != nil {
+		t.Fatalf("inserting group chat: %v", err)
+	}
+
+	return groupID, lastInsertID(t, result.LastInsertId)
+}
+
+func addTestMember(t *testing.T, groupID, userID int, status, role string) {
+	t.Helper()
+
+	_, err := DB.Exec("INSERT INTO group_members (group_id, user_id, status, role) VALUES (?, ?, ?, ?)", groupID, userID, status, role)
+	if err != nil {
+		t.Fatalf("inserting group member: %v", err)
+	}
+}
+
+func addTestFollower(t *testing.T, followerID, followingID int) {
+	t.Helper()
+
+	_, err := DB.Exec("INSERT INTO follows (follower_id, following_id, status) VALUES (?, ?, 'accepted')", followerID, followingID)
+	if err != nil {
+		t.Fatalf("inserting follow: %v", err)
+	}
+}
+
+// newTestPost inserts a published post, groupID 0 makes it a post outside groups
+func newTestPost(t *testing.T, userID, groupID int, privacyLevel string) int {
+	t.Helper()
+
+	post := Post{UserID: userID, Content: "Test post", PrivacyLevel: &privacyLevel}
+	if groupID != 0 {
+		post.GroupID = &groupID
+	}
+
+	postID, err := InsertPost(post)
+	if err != nil {
+		t.Fatalf("inserting post: %v", err)
+	}
+
+	return postID
+}
+
+func lastInsertID(t *testing.T, lastInsertId func() (int64, error)) int {
+	t.Helper()
+
+	id, err := lastInsertId()
+	if err != nil {
+		t.Fatalf("fetching last insert ID: %v", err)
+	}
+
+	return int(id)
+}
@@ -0,0 +1,3 @@
+ALTER TABLE comments ADD COLUMN parent_comment_id INTEGER REFERENCES comments (comment_id);
+ALTER TABLE comments ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_comments_parent_comment_id ON comments (parent_comment_id);
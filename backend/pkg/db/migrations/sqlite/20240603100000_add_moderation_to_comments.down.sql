@@ -0,0 +1,3 @@
+ALTER TABLE comments DROP COLUMN moderated_by;
+ALTER TABLE comments DROP COLUMN edited_at;
+ALTER TABLE comments DROP COLUMN status;
@@ -0,0 +1,3 @@
+ALTER TABLE comments ADD COLUMN status TEXT CHECK (status IN ('visible', 'hidden', 'deleted')) NOT NULL DEFAULT 'visible';
+ALTER TABLE comments ADD COLUMN edited_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN moderated_by INTEGER REFERENCES users (user_id);
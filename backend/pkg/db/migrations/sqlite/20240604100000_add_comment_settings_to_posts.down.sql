@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP COLUMN comments_locked;
+ALTER TABLE posts DROP COLUMN comment_policy;
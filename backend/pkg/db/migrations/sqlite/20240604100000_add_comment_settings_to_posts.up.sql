@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN comment_policy TEXT CHECK (comment_policy IN ('everyone', 'followers', 'nobody')) NOT NULL DEFAULT 'everyone';
+ALTER TABLE posts ADD COLUMN comments_locked BOOLEAN NOT NULL DEFAULT 0;
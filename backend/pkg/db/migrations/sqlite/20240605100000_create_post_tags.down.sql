@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_post_tags_created_at;
+DROP INDEX IF EXISTS idx_post_tags_tag_created_at;
+DROP TABLE IF EXISTS post_tags;
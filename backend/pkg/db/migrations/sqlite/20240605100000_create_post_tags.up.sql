@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id INTEGER NOT NULL,
+    tag VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, tag),
+    FOREIGN KEY (post_id) REFERENCES posts (post_id)
+);
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_created_at ON post_tags (tag, created_at);
+CREATE INDEX IF NOT EXISTS idx_post_tags_created_at ON post_tags (created_at);
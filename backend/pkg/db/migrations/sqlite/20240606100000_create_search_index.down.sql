@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS events_fts_update;
+DROP TRIGGER IF EXISTS events_fts_delete;
+DROP TRIGGER IF EXISTS events_fts_insert;
+DROP TABLE IF EXISTS events_fts;
+DROP TRIGGER IF EXISTS groups_fts_update;
+DROP TRIGGER IF EXISTS groups_fts_delete;
+DROP TRIGGER IF EXISTS groups_fts_insert;
+DROP TABLE IF EXISTS groups_fts;
+DROP TRIGGER IF EXISTS posts_fts_update;
+DROP TRIGGER IF EXISTS posts_fts_delete;
+DROP TRIGGER IF EXISTS posts_fts_insert;
+DROP TABLE IF EXISTS posts_fts;
+DROP TRIGGER IF EXISTS users_fts_update;
+DROP TRIGGER IF EXISTS users_fts_delete;
+DROP TRIGGER IF EXISTS users_fts_insert;
+DROP TABLE IF EXISTS users_fts;
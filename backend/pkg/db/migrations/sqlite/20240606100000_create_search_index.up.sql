@@ -0,0 +1,86 @@
+-- Full-text search indexes, kept in sync with their tables by triggers.
+-- FTS5 needs the backend to be built with the sqlite_fts5 tag.
+CREATE VIRTUAL TABLE IF NOT EXISTS users_fts USING fts5 (
+    firstname,
+    lastname,
+    nickname,
+    content = 'users',
+    content_rowid = 'user_id'
+);
+
+CREATE TRIGGER IF NOT EXISTS users_fts_insert AFTER INSERT ON users BEGIN
+    INSERT INTO users_fts (rowid, firstname, lastname, nickname) VALUES (new.user_id, new.firstname, new.lastname, new.nickname);
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_fts_delete AFTER DELETE ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, firstname, lastname, nickname) VALUES ('delete', old.user_id, old.firstname, old.lastname, old.nickname);
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_fts_update AFTER UPDATE OF firstname, lastname, nickname ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, firstname, lastname, nickname) VALUES ('delete', old.user_id, old.firstname, old.lastname, old.nickname);
+    INSERT INTO users_fts (rowid, firstname, lastname, nickname) VALUES (new.user_id, new.firstname, new.lastname, new.nickname);
+END;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS posts_fts USING fts5 (
+    content,
+    content = 'posts',
+    content_rowid = 'post_id'
+);
+
+CREATE TRIGGER IF NOT EXISTS posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (rowid, content) VALUES (new.post_id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_fts_delete AFTER DELETE ON posts BEGIN
+    INSERT INTO posts_fts (posts_fts, rowid, content) VALUES ('delete', old.post_id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_fts_update AFTER UPDATE OF content ON posts BEGIN
+    INSERT INTO posts_fts (posts_fts, rowid, content) VALUES ('delete', old.post_id, old.content);
+    INSERT INTO posts_fts (rowid, content) VALUES (new.post_id, new.content);
+END;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS groups_fts USING fts5 (
+    title,
+    content,
+    content = 'groups',
+    content_rowid = 'group_id'
+);
+
+CREATE TRIGGER IF NOT EXISTS groups_fts_insert AFTER INSERT ON groups BEGIN
+    INSERT INTO groups_fts (rowid, title, content) VALUES (new.group_id, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_fts_delete AFTER DELETE ON groups BEGIN
+    INSERT INTO groups_fts (groups_fts, rowid, title, content) VALUES ('delete', old.group_id, old.title, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_fts_update AFTER UPDATE OF title, content ON groups BEGIN
+    INSERT INTO groups_fts (groups_fts, rowid, title, content) VALUES ('delete', old.group_id, old.title, old.content);
+    INSERT INTO groups_fts (rowid, title, content) VALUES (new.group_id, new.title, new.content);
+END;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5 (
+    title,
+    content = 'events',
+    content_rowid = 'event_id'
+);
+
+CREATE TRIGGER IF NOT EXISTS events_fts_insert AFTER INSERT ON events BEGIN
+    INSERT INTO events_fts (rowid, title) VALUES (new.event_id, new.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_fts_delete AFTER DELETE ON events BEGIN
+    INSERT INTO events_fts (events_fts, rowid, title) VALUES ('delete', old.event_id, old.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_fts_update AFTER UPDATE OF title ON events BEGIN
+    INSERT INTO events_fts (events_fts, rowid, title) VALUES ('delete', old.event_id, old.title);
+    INSERT INTO events_fts (rowid, title) VALUES (new.event_id, new.title);
+END;
+
+-- Index the rows that existed before the search was added
+INSERT INTO users_fts (users_fts) VALUES ('rebuild');
+INSERT INTO posts_fts (posts_fts) VALUES ('rebuild');
+INSERT INTO groups_fts (groups_fts) VALUES ('rebuild');
+INSERT INTO events_fts (events_fts) VALUES ('rebuild');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_reshared_post_id;
+ALTER TABLE posts DROP COLUMN reshared_post_id;
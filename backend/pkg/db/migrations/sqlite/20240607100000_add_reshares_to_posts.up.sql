@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN reshared_post_id INTEGER REFERENCES posts (post_id);
+CREATE INDEX IF NOT EXISTS idx_posts_reshared_post_id ON posts (reshared_post_id);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    collection_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
+
+-- A saved post is in at most one collection, collection_id is NULL for unsorted posts
+CREATE TABLE IF NOT EXISTS bookmarks (
+    user_id INTEGER NOT NULL,
+    post_id INTEGER NOT NULL,
+    collection_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id),
+    FOREIGN KEY (post_id) REFERENCES posts (post_id),
+    FOREIGN KEY (collection_id) REFERENCES bookmark_collections (collection_id)
+);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created_at ON bookmarks (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_collection_id ON bookmarks (collection_id);
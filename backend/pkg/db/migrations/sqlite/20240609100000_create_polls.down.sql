@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS poll_votes_check;
+DROP TABLE IF EXISTS poll_votes;
+DROP TABLE IF EXISTS poll_options;
+DROP TABLE IF EXISTS polls;
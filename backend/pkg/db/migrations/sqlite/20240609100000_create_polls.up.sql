@@ -0,0 +1,41 @@
+-- A poll belongs to a post, the post content is the question
+CREATE TABLE IF NOT EXISTS polls (
+    post_id INTEGER PRIMARY KEY,
+    multiple_choice BOOLEAN NOT NULL DEFAULT 0,
+    closes_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (post_id) REFERENCES posts (post_id)
+);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    option_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    text VARCHAR(200) NOT NULL,
+    UNIQUE (post_id, position),
+    FOREIGN KEY (post_id) REFERENCES polls (post_id)
+);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    post_id INTEGER NOT NULL,
+    option_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (option_id, user_id),
+    FOREIGN KEY (post_id) REFERENCES polls (post_id),
+    FOREIGN KEY (option_id) REFERENCES poll_options (option_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_poll_votes_post_user ON poll_votes (post_id, user_id);
+
+CREATE TRIGGER IF NOT EXISTS poll_votes_check BEFORE INSERT ON poll_votes BEGIN
+    SELECT RAISE(ABORT, 'option does not belong to the poll')
+    WHERE NOT EXISTS (SELECT 1 FROM poll_options WHERE option_id = new.option_id AND post_id = new.post_id);
+
+    SELECT RAISE(ABORT, 'poll is closed')
+    WHERE EXISTS (SELECT 1 FROM polls WHERE post_id = new.post_id AND closes_at IS NOT NULL AND closes_at <= CURRENT_TIMESTAMP);
+
+    SELECT RAISE(ABORT, 'single choice poll allows one vote per user')
+    WHERE EXISTS (SELECT 1 FROM polls WHERE post_id = new.post_id AND multiple_choice = 0)
+      AND EXISTS (SELECT 1 FROM poll_votes WHERE post_id = new.post_id AND user_id = new.user_id);
+END;
@@ -0,0 +1,24 @@
+-- Images of posts and comments, in display order. Exactly one of post_id and comment_id is set.
+CREATE TABLE IF NOT EXISTS media (
+    media_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER,
+    comment_id INTEGER,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    alt_text VARCHAR(500) NOT NULL DEFAULT '',
+    width INTEGER,
+    height INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((post_id IS NULL) != (comment_id IS NULL)),
+    FOREIGN KEY (post_id) REFERENCES posts (post_id),
+    FOREIGN KEY (comment_id) REFERENCES comments (comment_id)
+);
+CREATE INDEX IF NOT EXISTS idx_media_post_position ON media (post_id, position);
+CREATE INDEX IF NOT EXISTS idx_media_comment_position ON media (comment_id, position);
+
+-- Existing single images become the first media item
+INSERT INTO media (post_id, position, url)
+SELECT post_id, 0, post_image FROM posts WHERE post_image IS NOT NULL AND post_image != '';
+
+INSERT INTO media (comment_id, position, url)
+SELECT comment_id, 0, comment_image FROM comments WHERE comment_image IS NOT NULL AND comment_image != '';
@@ -0,0 +1,4 @@
+-- Drafts are never published on their own, scheduled posts are published by the scheduler at publish_at
+ALTER TABLE posts ADD COLUMN status TEXT CHECK (status IN ('published', 'draft', 'scheduled')) NOT NULL DEFAULT 'published';
+ALTER TABLE posts ADD COLUMN publish_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_posts_status_publish_at ON posts (status, publish_at);
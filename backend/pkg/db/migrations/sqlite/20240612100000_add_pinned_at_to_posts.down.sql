@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_pinned_at;
+ALTER TABLE posts DROP COLUMN pinned_at;
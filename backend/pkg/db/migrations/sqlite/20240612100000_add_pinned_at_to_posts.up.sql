@@ -0,0 +1,3 @@
+-- Pinned posts come first, on the author's profile or, for group posts, in the group
+ALTER TABLE posts ADD COLUMN pinned_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_posts_pinned_at ON posts (pinned_at) WHERE pinned_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS story_views;
+DROP INDEX IF EXISTS idx_stories_expires_at;
+DROP INDEX IF EXISTS idx_stories_user_id;
+DROP TABLE IF EXISTS stories;
@@ -0,0 +1,25 @@
+-- Stories are short lived, expired stories are deleted by the cleanup job together with their image
+CREATE TABLE IF NOT EXISTS stories (
+    story_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    image_url TEXT,
+    alt_text TEXT NOT NULL DEFAULT '',
+    width INTEGER,
+    height INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stories_user_id ON stories (user_id, expires_at);
+CREATE INDEX IF NOT EXISTS idx_stories_expires_at ON stories (expires_at);
+
+CREATE TABLE IF NOT EXISTS story_views (
+    story_id INTEGER NOT NULL,
+    viewer_id INTEGER NOT NULL,
+    viewed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (story_id, viewer_id),
+    FOREIGN KEY (story_id) REFERENCES stories(story_id),
+    FOREIGN KEY (viewer_id) REFERENCES users(user_id)
+);
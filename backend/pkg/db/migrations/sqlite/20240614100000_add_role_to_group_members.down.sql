@@ -0,0 +1 @@
+ALTER TABLE group_members DROP COLUMN role;
@@ -0,0 +1,9 @@
+-- Every accepted member has a role, the owner is also kept in groups.user_id
+ALTER TABLE group_members ADD COLUMN role TEXT CHECK (role IN ('owner', 'admin', 'moderator', 'member')) NOT NULL DEFAULT 'member';
+
+-- Group creators own their groups
+INSERT OR IGNORE INTO group_members (group_id, user_id, status)
+SELECT group_id, user_id, 'accepted' FROM groups;
+
+UPDATE group_members SET role = 'owner', status = 'accepted'
+WHERE EXISTS (SELECT 1 FROM groups g WHERE g.group_id = group_members.group_id AND g.user_id = group_members.user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_group_moderation_log_group_id;
+DROP TABLE IF EXISTS group_moderation_log;
+DROP TABLE IF EXISTS group_bans;
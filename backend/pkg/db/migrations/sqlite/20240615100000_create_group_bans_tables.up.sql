@@ -0,0 +1,27 @@
+-- Banned users can't join the group or be invited to it until they are unbanned
+CREATE TABLE IF NOT EXISTS group_bans (
+    group_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    banned_by INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id),
+    FOREIGN KEY (group_id) REFERENCES groups (group_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id),
+    FOREIGN KEY (banned_by) REFERENCES users (user_id)
+);
+
+-- Audit of the removals, bans and unbans in a group
+CREATE TABLE IF NOT EXISTS group_moderation_log (
+    log_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    action TEXT CHECK (action IN ('remove', 'ban', 'unban')) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups (group_id),
+    FOREIGN KEY (actor_id) REFERENCES users (user_id),
+    FOREIGN KEY (target_id) REFERENCES users (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_group_moderation_log_group_id ON group_moderation_log (group_id, created_at);
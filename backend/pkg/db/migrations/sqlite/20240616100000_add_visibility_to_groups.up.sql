@@ -0,0 +1,3 @@
+-- public: posts readable by anyone, joined instantly; closed: listed, members-only posts, joined by request;
+-- secret: unlisted and invite-only. Existing groups keep working as closed groups.
+ALTER TABLE groups ADD COLUMN visibility TEXT CHECK (visibility IN ('public', 'closed', 'secret')) NOT NULL DEFAULT 'closed';
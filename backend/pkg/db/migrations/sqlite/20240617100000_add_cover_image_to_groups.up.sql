@@ -0,0 +1,2 @@
+-- URL of the uploaded cover image, NULL when the group has none
+ALTER TABLE groups ADD COLUMN cover_image TEXT;
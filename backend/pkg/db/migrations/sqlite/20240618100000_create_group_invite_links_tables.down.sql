@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_group_invite_link_uses_link_id;
+DROP TABLE IF EXISTS group_invite_link_uses;
+DROP INDEX IF EXISTS idx_group_invite_links_group_id;
+DROP TABLE IF EXISTS group_invite_links;
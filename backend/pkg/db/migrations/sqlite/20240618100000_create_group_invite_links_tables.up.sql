@@ -0,0 +1,27 @@
+-- Invite links let anyone holding the token join the group without a request. NULL max_uses and
+-- expires_at mean no limit.
+CREATE TABLE IF NOT EXISTS group_invite_links (
+    link_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    created_by INTEGER NOT NULL,
+    max_uses INTEGER CHECK (max_uses IS NULL OR max_uses > 0),
+    use_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups (group_id),
+    FOREIGN KEY (created_by) REFERENCES users (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_group_invite_links_group_id ON group_invite_links (group_id);
+
+-- Audit of who joined through which link
+CREATE TABLE IF NOT EXISTS group_invite_link_uses (
+    use_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    link_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (link_id) REFERENCES group_invite_links (link_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_group_invite_link_uses_link_id ON group_invite_link_uses (link_id, created_at);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS group_members_joined_update;
+DROP TRIGGER IF EXISTS group_members_joined_insert;
+ALTER TABLE group_members DROP COLUMN joined_at;
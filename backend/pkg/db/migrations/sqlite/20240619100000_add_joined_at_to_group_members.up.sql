@@ -0,0 +1,14 @@
+-- When the user last became an accepted member, kept up to date by the triggers below
+ALTER TABLE group_members ADD COLUMN joined_at TIMESTAMP;
+
+UPDATE group_members SET joined_at = created_at WHERE status = 'accepted';
+
+CREATE TRIGGER IF NOT EXISTS group_members_joined_insert AFTER INSERT ON group_members
+WHEN new.status = 'accepted' BEGIN
+    UPDATE group_members SET joined_at = CURRENT_TIMESTAMP WHERE group_id = new.group_id AND user_id = new.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS group_members_joined_update AFTER UPDATE OF status ON group_members
+WHEN new.status = 'accepted' AND old.status != 'accepted' BEGIN
+    UPDATE group_members SET joined_at = CURRENT_TIMESTAMP WHERE group_id = new.group_id AND user_id = new.user_id;
+END;
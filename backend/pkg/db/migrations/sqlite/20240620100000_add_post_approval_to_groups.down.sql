@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posts_group_approval;
+ALTER TABLE posts DROP COLUMN reviewed_at;
+ALTER TABLE posts DROP COLUMN reviewed_by;
+ALTER TABLE posts DROP COLUMN approval_status;
+ALTER TABLE groups DROP COLUMN posts_require_approval;
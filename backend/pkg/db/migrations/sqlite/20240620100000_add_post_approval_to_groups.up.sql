@@ -0,0 +1,8 @@
+-- Groups can hold the posts of their members for review by a moderator before they show up
+ALTER TABLE groups ADD COLUMN posts_require_approval BOOLEAN NOT NULL DEFAULT 0;
+
+ALTER TABLE posts ADD COLUMN approval_status TEXT CHECK (approval_status IN ('approved', 'pending', 'rejected')) NOT NULL DEFAULT 'approved';
+ALTER TABLE posts ADD COLUMN reviewed_by INTEGER REFERENCES users (user_id);
+ALTER TABLE posts ADD COLUMN reviewed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_posts_group_approval ON posts (group_id, approval_status);
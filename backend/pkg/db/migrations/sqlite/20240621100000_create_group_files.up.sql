@@ -0,0 +1,15 @@
+-- Files shared in a group. They are stored under group-files/<group_id>/stored_name, outside the public
+-- uploads directory, and only served to members.
+CREATE TABLE IF NOT EXISTS group_files (
+    file_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    stored_name TEXT NOT NULL UNIQUE,
+    mime_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups (group_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_group_files_group_id ON group_files (group_id, created_at);
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func SavePostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/save-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional, without a collection the post is saved as unsorted
+	var request db.SaveBookmarkRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			http.Error(w, "Error decoding request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !checkPostVisible(w, postID, userID) {
+		return
+	}
+
+	err = db.SavePost(userID, postID, request.CollectionID)
+	if err != nil {
+		if err == db.ErrCollectionNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to save post", http.StatusInternalServerError)
+		log.Printf("Error saving post: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Post saved successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func UnsavePostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/unsave-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	err = db.UnsavePost(userID, postID)
+	if err != nil {
+		http.Error(w, "Failed to unsave post", http.StatusInternalServerError)
+		log.Printf("Error unsaving post: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Post removed from saved posts"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSavedPostsHandler lists the user's saved posts, all of them or those of the collection_id query parameter
+func GetSavedPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	var collectionID *int
+	if collectionIDStr := r.URL.Query().Get("collection_id"); collectionIDStr != "" {
+		id, err := strconv.Atoi(collectionIDStr)
+		if err != nil {
+			http.Error(w, "Invalid collectionID", http.StatusBadRequest)
+			return
+		}
+		collectionID = &id
+	}
+
+	limit, offset := paginationFromQuery(r, 20, 100)
+
+	posts, err := db.GetSavedPosts(userID, collectionID, limit, offset)
+	if err != nil {
+		if err == db.ErrCollectionNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch saved posts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(posts); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetBookmarkCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	collections, err := db.GetBookmarkCollections(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch collections", http.StatusInternalServerError)
+		log.Printf("Error fetching bookmark collections: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(collections); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func CreateBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	var collection db.BookmarkCollection
+	err := json.NewDecoder(r.Body).Decode(&collection)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	name, ok := db.ValidCollectionName(collection.Name)
+	if !ok {
+		http.Error(w, "Invalid collection name", http.StatusBadRequest)
+		return
+	}
+
+	collectionID, err := db.CreateBookmarkCollection(userID, name)
+	if err != nil {
+		if err == db.ErrCollectionExists {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create collection", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{"message": "Collection created successfully", "collection_id": collectionID}
+	json.NewEncoder(w).Encode(response)
+}
+
+func RenameBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	collectionIDStr := r.URL.Path[len("/api/rename-bookmark-collection/"):]
+	collectionID, err := strconv.Atoi(collectionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid collectionID", http.StatusBadRequest)
+		return
+	}
+
+	var collection db.BookmarkCollection
+	err = json.NewDecoder(r.Body).Decode(&collection)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	name, ok := db.ValidCollectionName(collection.Name)
+	if !ok {
+		http.Error(w, "Invalid collection name", http.StatusBadRequest)
+		return
+	}
+
+	err = db.RenameBookmarkCollection(collectionID, userID, name)
+	if err != nil {
+		switch err {
+		case db.ErrCollectionNotFound:
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		case db.ErrCollectionExists:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to rename collection", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := map[string]string{"message": "Collection renamed successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func DeleteBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	collectionIDStr := r.URL.Path[len("/api/delete-bookmark-collection/"):]
+	collectionID, err := strconv.Atoi(collectionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid collectionID", http.StatusBadRequest)
+		return
+	}
+
+	err = db.DeleteBookmarkCollection(collectionID, userID)
+	if err != nil {
+		if err == db.ErrCollectionNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete collection", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Collection deleted successfully"}
+	json.NewEncoder(w).Encode(response)
+}
@@ -6,13 +6,24 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-// Map to keep track of connections by chat room
-var chatRooms = make(map[string][]*websocket.Conn)
+// chatConnection is a connection of a chat room together with the user it belongs to
+type chatConnection struct {
+	conn   *websocket.Conn
+	userID int
+}
+
+var (
+	// Map to keep track of connections by chat room
+	chatRooms = make(map[string][]chatConnection)
+	// chatRoomsMu guards chatRooms, which the goroutines of all chat connections share
+	chatRoomsMu sync.Mutex
+)
 
 func GetChatsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -63,6 +74,17 @@ func GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	allowed, err := db.CanAccessChat(chatID, userID)
+	if err != nil {
+		log.Printf("Error checking chat access: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, "You are not a participant of this chat", http.StatusForbidden)
+		return
+	}
+
 	rows, err := db.DB.Query("SELECT message_id, chat_id, sender_id, content, emoji, created_at FROM messages WHERE chat_id = ?", chatID)
 	if err != nil {
 		log.Printf("Error querying database: %v", err)
@@ -99,6 +121,12 @@ func GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := Upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
@@ -120,15 +148,35 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		// Messages are always sent as the user of the session, whatever the client claims
+		chatMessage.SenderID = userID
+		message, err = withSenderID(message, userID)
+		if err != nil {
+			log.Println("Error parsing message:", err)
+			continue
+		}
+
+		// Chats are closed to those who aren't participants, or members of the group for group chats
+		allowed, err := db.CanAccessChat(chatMessage.ChatID, userID)
+		if err != nil {
+			log.Printf("Error checking chat access: %v", err)
+			continue
+		}
+		if !allowed {
+			continue
+		}
+
 		strChatID := strconv.Itoa(chatMessage.ChatID)
 
+		chatRoomsMu.Lock()
 		if _, ok := chatRooms[strChatID]; !ok {
-			chatRooms[strChatID] = []*websocket.Conn{}
+			chatRooms[strChatID] = []chatConnection{}
 		}
 
 		if !containsConnection(chatRooms[strChatID], conn) {
-			chatRooms[strChatID] = append(chatRooms[strChatID], conn)
+			chatRooms[strChatID] = append(chatRooms[strChatID], chatConnection{conn: conn, userID: userID})
 		}
+		chatRoomsMu.Unlock()
 
 		if !(chatMessage.Content == "" || chatMessage.SenderID == 0) {
 			if err := db.InsertChatMessage(chatMessage); err != nil {
@@ -136,6 +184,10 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
+			if err := db.CreateChatMentionNotifications(chatMessage.ChatID, chatMessage.SenderID, chatMessage.Content); err != nil {
+				log.Printf("Error creating chat mention notifications: %v", err)
+			}
+
 			// Add the message to the unread_messages table for each participant in the chat
 			participants, err := db.GetChatParticipants(chatMessage.ChatID)
 			if err != nil {
@@ -159,21 +211,60 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			// Broadcast message to all participants in the chat room
+			// Broadcast message to all participants in the chat room. Those who lost access since
+			// they joined the room, like members who left the group, are dropped from it instead.
+			chatRoomsMu.Lock()
+			room := append([]chatConnection(nil), chatRooms[strChatID]...)
+			chatRoomsMu.Unlock()
+
+			denied := make(map[*websocket.Conn]bool)
+			for _, participant := range room {
+				allowed, err := db.CanAccessChat(chatMessage.ChatID, participant.userID)
+				if err != nil {
+					log.Printf("Error checking chat access: %v", err)
+					continue
+				}
+				if !allowed {
+					denied[participant.conn] = true
+				}
+			}
+
+			// The room may have changed meanwhile, so it is rebuilt from its current connections. Writing
+			// under the lock also keeps two goroutines from writing to one connection at the same time.
+			chatRoomsMu.Lock()
+			connections := make([]chatConnection, 0, len(chatRooms[strChatID]))
 			for _, participant := range chatRooms[strChatID] {
+				if denied[participant.conn] {
+					continue
+				}
+				connections = append(connections, participant)
+
 				// Now, we send the message to every participant, including the sender
-				if err := participant.WriteMessage(websocket.TextMessage, message); err != nil {
+				if err := participant.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 					log.Println(err)
 				}
 			}
+			chatRooms[strChatID] = connections
+			chatRoomsMu.Unlock()
 		}
 	}
 }
 
+// withSenderID rewrites the sender of a raw chat message, keeping the other fields as the client sent them
+func withSenderID(message []byte, senderID int) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(message, &fields); err != nil {
+		return nil, err
+	}
+
+	fields["sender_id"] = senderID
+	return json.Marshal(fields)
+}
+
 // containsConnection checks if the connection is already in the list of connections
-func containsConnection(connections []*websocket.Conn, conn *websocket.Conn) bool {
+func containsConnection(connections []chatConnection, conn *websocket.Conn) bool {
 	for _, c := range connections {
-		if c == conn {
+		if c.conn == conn {
 			return true
 		}
 	}
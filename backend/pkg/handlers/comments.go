@@ -3,10 +3,11 @@ package handlers
 import (
 	"backend/pkg/db"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 )
 
 func CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
@@ -43,31 +44,75 @@ func CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
 	commentData.UserID = userID
 	commentData.PostID = postID
 
-	if commentData.CommentImage != nil && *commentData.CommentImage != "" {
+	if !checkPostVisible(w, postID, userID) {
+		return
+	}
 
-		cwd, err := os.Getwd()
-		if err != nil {
-			log.Fatalf("Post: Error getting current working directory: %v", err)
-		}
+	// Respect who the author allows to comment and whether comments are locked
+	err = db.CanCommentOnPost(postID, userID)
+	if errors.Is(err, db.ErrCommentsLocked) || errors.Is(err, db.ErrCommentsDisabled) || errors.Is(err, db.ErrCommentsFollowersOnly) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to check comment settings", http.StatusInternalServerError)
+		return
+	}
 
-		dirPath := cwd + "/uploads/comment-image"
+	// Clients that only send comment_image get it as the single media item
+	if len(commentData.Media) == 0 && commentData.CommentImage != nil && *commentData.CommentImage != "" {
+		commentData.Media = []db.Media{{Data: *commentData.CommentImage}}
+	}
 
-		fileName, err := saveBase64File(*commentData.CommentImage, dirPath)
-		if err != nil {
-			http.Error(w, "Failed to save comment image", http.StatusInternalServerError)
+	if err := db.ValidateMedia(commentData.Media, db.MaxCommentMedia); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = saveMedia(commentData.Media, "comment-image")
+	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			http.Error(w, "Invalid image", http.StatusBadRequest)
 			return
 		}
-		webPath := "http://localhost:8000/uploads/comment-image/" + fileName
-		commentData.CommentImage = &webPath
+		http.Error(w, "Failed to save comment image", http.StatusInternalServerError)
+		log.Printf("Failed to save media: %v", err)
+		return
+	}
+
+	commentData.CommentImage = nil
+	if len(commentData.Media) > 0 {
+		commentData.CommentImage = &commentData.Media[0].URL
 	}
 
-	err = db.InsertComment(commentData)
+	commentID, err := db.InsertComment(commentData)
+	if errors.Is(err, db.ErrParentCommentNotFound) || errors.Is(err, db.ErrCommentTooDeep) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to insert comment data", http.StatusInternalServerError)
 		log.Printf("Failed to insert comment data: %v", err)
 		return
 	}
 
+	if commentData.ParentCommentID != nil {
+		err = db.CreateCommentReplyNotification(userID, *commentData.ParentCommentID)
+		if err != nil {
+			log.Printf("Error creating comment reply notification: %v", err)
+		}
+	}
+
+	err = db.CreatePostCommentNotification(userID, commentID)
+	if err != nil {
+		log.Printf("Error creating post comment notification: %v", err)
+	}
+
+	err = db.CreateCommentMentionNotifications(commentID)
+	if err != nil {
+		log.Printf("Error creating comment mention notifications: %v", err)
+	}
+
 	response := map[string]string{"message": "Comment created successfully"}
 	json.NewEncoder(w).Encode(response)
 }
@@ -88,16 +133,246 @@ func GetCommentsFromPostIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comments, err := db.GetCommentsForPost(postID)
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	if !checkPostVisible(w, postID, userID) {
+		return
+	}
+
+	comments, err := db.GetCommentsForPost(postID, userID)
 	if err != nil {
 		http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
 		return
 	}
 
-
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(comments); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
-}
\ No newline at end of file
+}
+
+func GetCommentRepliesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the dynamic part of the URL
+	commentIDStr := r.URL.Path[len("/api/get-comment-replies/"):]
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid commentID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkCommentVisible(w, commentID, userID) {
+		return
+	}
+
+	limit, offset := paginationFromQuery(r, 10, 50)
+
+	replies, err := db.GetCommentReplies(commentID, userID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch replies", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(replies); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func EditCommentHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	commentIDStr := r.URL.Path[len("/api/edit-comment/"):]
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid commentID", http.StatusBadRequest)
+		return
+	}
+
+	var commentData db.Comment
+	err = json.NewDecoder(r.Body).Decode(&commentData)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(commentData.Content) == "" {
+		http.Error(w, "Comment content cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := db.GetCommentByID(commentID)
+	if err != nil || comment.Status == db.CommentDeleted {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	// Only the author can change what a comment says
+	if comment.UserID != userID {
+		http.Error(w, "You can only edit your own comments", http.StatusForbidden)
+		return
+	}
+
+	err = db.UpdateCommentContent(commentID, commentData.Content)
+	if err != nil {
+		http.Error(w, "Failed to edit comment", http.StatusInternalServerError)
+		log.Printf("Failed to edit comment: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Comment edited successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	commentIDStr := r.URL.Path[len("/api/delete-comment/"):]
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid commentID", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := db.GetCommentByID(commentID)
+	if err != nil || comment.Status == db.CommentDeleted {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	// Authors delete their own comments, post authors and group admins moderate the rest
+	var moderatorID *int
+	if comment.UserID != userID {
+		canModerate, err := db.CanModeratePostComments(comment.PostID, userID)
+		if err != nil {
+			http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+			return
+		}
+		if !canModerate {
+			http.Error(w, "You are not allowed to delete this comment", http.StatusForbidden)
+			return
+		}
+		moderatorID = &userID
+	}
+
+	fileURLs, err := db.DeleteComment(commentID, moderatorID)
+	if err != nil {
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		log.Printf("Failed to delete comment: %v", err)
+		return
+	}
+
+	for _, url := range fileURLs {
+		if err := removeUploadedFile(url); err != nil {
+			log.Printf("Error removing comment image: %v", err)
+		}
+	}
+
+	response := map[string]string{"message": "Comment deleted successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func HideCommentHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	commentIDStr := r.URL.Path[len("/api/hide-comment/"):]
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid commentID", http.StatusBadRequest)
+		return
+	}
+
+	var hideReq db.HideCommentRequest
+	err = json.NewDecoder(r.Body).Decode(&hideReq)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := db.GetCommentByID(commentID)
+	if err != nil || comment.Status == db.CommentDeleted {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	canModerate, err := db.CanModeratePostComments(comment.PostID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+		return
+	}
+	if !canModerate {
+		http.Error(w, "You are not allowed to moderate this comment", http.StatusForbidden)
+		return
+	}
+
+	err = db.SetCommentHidden(commentID, userID, hideReq.Hidden)
+	if err != nil {
+		http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+		log.Printf("Failed to update comment visibility: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Comment updated successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkCommentVisible writes an error response and returns false if the user may not see the comment's post
+func checkCommentVisible(w http.ResponseWriter, commentID, userID int) bool {
+	postID, err := db.GetCommentPostID(commentID)
+	if err != nil {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		log.Printf("Error fetching comment: %v", err)
+		return false
+	}
+
+	return checkPostVisible(w, postID, userID)
+}
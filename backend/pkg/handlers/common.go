@@ -2,13 +2,19 @@ package handlers
 
 import (
 	"backend/pkg/db"
+	"bytes"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -55,6 +61,25 @@ func userIDFromSession(r *http.Request) int {
 	return userID
 }
 
+// paginationFromQuery reads the limit and offset query parameters, falling back to
+// defaultLimit and capping the limit at maxLimit
+func paginationFromQuery(r *http.Request, defaultLimit, maxLimit int) (limit, offset int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	offset, err = strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
 func extractSessionToken(r *http.Request) string {
 	const sessionToken = "session_token"
 	cookie, err := r.Cookie(sessionToken)
@@ -86,33 +111,35 @@ func CustomFileServer(root http.FileSystem) http.Handler {
 	})
 }
 
-func saveBase64File(base64Data, directoryPath string) (string, error) {
-
+// decodeBase64Data decodes a data URL like data:image/png;base64,... and returns its MIME type and content
+func decodeBase64Data(base64Data string) (string, []byte, error) {
 	// Split the string to separate the metadata from the data itself
 	dataParts := strings.Split(base64Data, ",")
 	if len(dataParts) != 2 {
-		return "", errors.New("invalid base64 data")
+		return "", nil, errors.New("invalid base64 data")
 	}
 
 	// Decode the file data from base64
 	decodedData, err := base64.StdEncoding.DecodeString(dataParts[1])
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	// Extract MIME type and choose file extension
 	mimeType := strings.Split(dataParts[0], ";")[0]
 	mimeType = strings.TrimPrefix(mimeType, "data:")
 
-	var fileExtension string
-	switch mimeType {
-	case "image/jpeg":
-		fileExtension = ".jpg"
-	case "image/png":
-		fileExtension = ".png"
-	case "image/gif":
-		fileExtension = ".gif"
-	default:
+	return mimeType, decodedData, nil
+}
+
+func saveBase64File(base64Data, directoryPath string) (string, error) {
+	mimeType, decodedData, err := decodeBase64Data(base64Data)
+	if err != nil {
+		return "", err
+	}
+
+	// Choose the file extension from the MIME type
+	fileExtension, ok := imageExtension(mimeType)
+	if !ok {
 		return "", errors.New("unsupported file type")
 	}
 
@@ -129,3 +156,99 @@ func saveBase64File(base64Data, directoryPath string) (string, error) {
 
 	return fileName, nil
 }
+
+// imageExtension returns the extension images of the MIME type are saved with, or false if
+// the type isn't a supported image
+func imageExtension(mimeType string) (string, bool) {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg", true
+	case "image/png":
+		return ".png", true
+	case "image/gif":
+		return ".gif", true
+	}
+	return "", false
+}
+
+// errInvalidMedia is wrapped by the errors of saveMedia for data that isn't a supported image,
+// handlers answer those with a bad request
+var errInvalidMedia = errors.New("invalid image")
+
+// saveMedia saves uploaded images to a subdirectory of uploads and replaces their data
+// with the URL and the dimensions of the saved file. All images are checked before any is
+// written, and the ones already written are removed if a later one fails.
+func saveMedia(media []db.Media, subdirectory string) error {
+	if len(media) == 0 {
+		return nil
+	}
+
+	contents := make([][]byte, len(media))
+	extensions := make([]string, len(media))
+	for i := range media {
+		mimeType, content, err := decodeBase64Data(media[i].Data)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidMedia, err)
+		}
+
+		extension, ok := imageExtension(mimeType)
+		if !ok {
+			return fmt.Errorf("%w: unsupported file type %q", errInvalidMedia, mimeType)
+		}
+
+		config, _, err := image.DecodeConfig(bytes.NewReader(content))
+		if err != nil {
+			return fmt.Errorf("%w: failed to read image dimensions: %v", errInvalidMedia, err)
+		}
+
+		contents[i] = content
+		extensions[i] = extension
+		media[i].Width = &config.Width
+		media[i].Height = &config.Height
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	dirPath := cwd + "/uploads/" + subdirectory
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return err
+	}
+
+	for i := range media {
+		fileName := generateUniqueFileName(extensions[i])
+		if err := os.WriteFile(dirPath+"/"+fileName, contents[i], 0666); err != nil {
+			for _, saved := range media[:i] {
+				removeUploadedFile(saved.URL)
+			}
+			return err
+		}
+
+		media[i].URL = "http://localhost:8000/uploads/" + subdirectory + "/" + fileName
+		media[i].Data = ""
+	}
+
+	return nil
+}
+
+// removeUploadedFile deletes a file saved by saveMedia, given its URL
+func removeUploadedFile(url string) error {
+	relativePath, ok := strings.CutPrefix(url, "http://localhost:8000/")
+	if !ok || !strings.HasPrefix(relativePath, "uploads/") || strings.Contains(relativePath, "..") {
+		return fmt.Errorf("removeUploadedFile: not an uploaded file: %s", url)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(cwd + "/" + relativePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
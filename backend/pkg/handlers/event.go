@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"backend/pkg/db"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -32,17 +33,23 @@ func CreateEventHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = db.InsertEvent(eventData)
+	groupIDStr := r.URL.Path[len("/api/create-event/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
 	if err != nil {
-		http.Error(w, "Failed to insert group data", http.StatusInternalServerError)
-		log.Printf("Failed to insert group data: %v", err)
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
 		return
 	}
 
-	groupIDStr := r.URL.Path[len("/api/create-event/"):]
-	groupID, err := strconv.Atoi(groupIDStr)
+	// Events are created by the moderators and above, in the group they were checked against
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+	eventData.GroupID = groupID
+
+	err = db.InsertEvent(eventData)
 	if err != nil {
-		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		http.Error(w, "Failed to insert group data", http.StatusInternalServerError)
+		log.Printf("Failed to insert group data: %v", err)
 		return
 	}
 
@@ -65,6 +72,12 @@ func GetEventsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
 	// Parse the dynamic part of the URL
 	groupIDStr := r.URL.Path[len("/api/get-events/"):]
 	groupID, err := strconv.Atoi(groupIDStr)
@@ -73,6 +86,10 @@ func GetEventsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkGroupReadable(w, groupID, userID) {
+		return
+	}
+
 	events, err := db.GetEvents(groupID)
 	if err != nil {
 		http.Error(w, "GetEvents: Failed to fetch events", http.StatusInternalServerError)
@@ -112,6 +129,22 @@ func UpdateAttendeesStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only members of the event's group can answer it
+	groupID, err := db.GetEventGroupID(eventID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch event", http.StatusInternalServerError)
+		log.Printf("UpdateAttendeesStatus: Failed to fetch event: %v", err)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleMember) {
+		return
+	}
+
 	// Decode the request body to get the updated status
 	var updatedStatus db.AttendeesStatus
 	err = json.NewDecoder(r.Body).Decode(&updatedStatus)
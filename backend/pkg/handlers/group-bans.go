@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func RemoveGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
+	moderateGroupMember(w, r, "/api/remove-group-member/", false)
+}
+
+// BanGroupMemberHandler removes the user from the group and keeps them from joining or being invited again
+func BanGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
+	moderateGroupMember(w, r, "/api/ban-group-member/", true)
+}
+
+// moderateGroupMember removes or bans the user named in the request body. Moderators and above
+// may do so, but only to users of a lower role than their own.
+func moderateGroupMember(w http.ResponseWriter, r *http.Request, pathPrefix string, ban bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len(pathPrefix):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.GroupModerationRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	reason, ok := db.ValidModerationReason(request.Reason)
+	if !ok {
+		http.Error(w, "Reason is too long", http.StatusBadRequest)
+		return
+	}
+
+	if request.UserID == userID {
+		http.Error(w, "Use leave group to leave the group", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	actorRole, err := db.GetGroupRole(groupID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group role", http.StatusInternalServerError)
+		return
+	}
+
+	targetRole, err := db.GetGroupRole(groupID, request.UserID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group role", http.StatusInternalServerError)
+		return
+	}
+
+	if !db.GroupRoleOutranks(actorRole, targetRole) {
+		http.Error(w, db.ErrGroupPermission.Error(), http.StatusForbidden)
+		return
+	}
+
+	fileURLs, err := db.RemoveGroupMember(groupID, userID, request.UserID, reason, ban)
+	if err != nil {
+		if err == db.ErrNotGroupMember {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to remove group member", http.StatusInternalServerError)
+		log.Printf("Error removing group member: %v", err)
+		return
+	}
+
+	// The images of the member's cancelled drafts and scheduled posts
+	for _, url := range fileURLs {
+		if err := removeUploadedFile(url); err != nil {
+			log.Printf("Error removing post image: %v", err)
+		}
+	}
+
+	message := "Member removed successfully"
+	if ban {
+		message = "User banned successfully"
+	}
+
+	response := map[string]string{"message": message}
+	json.NewEncoder(w).Encode(response)
+}
+
+func UnbanGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/unban-group-member/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.GroupModerationRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	err = db.UnbanGroupMember(groupID, userID, request.UserID)
+	if err != nil {
+		if err == db.ErrUserNotBanned {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to unban user", http.StatusInternalServerError)
+		log.Printf("Error unbanning group member: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "User unbanned successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func GetGroupBansHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-bans/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	bans, err := db.GetGroupBans(groupID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group bans", http.StatusInternalServerError)
+		log.Printf("Error fetching group bans: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(bans); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetGroupModerationLogHandler shows who removed, banned or unbanned whom in a group
+func GetGroupModerationLogHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-moderation-log/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	limit, offset := paginationFromQuery(r, 50, 200)
+
+	entries, err := db.GetGroupModerationLog(groupID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch moderation log", http.StatusInternalServerError)
+		log.Printf("Error fetching group moderation log: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
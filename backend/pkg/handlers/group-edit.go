@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// UpdateGroupHandler lets admins and the owner change the title, description and cover image of a group
+func UpdateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/update-group/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var update db.GroupUpdate
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := db.ValidateGroupUpdate(&update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleAdmin) {
+		return
+	}
+
+	if update.CoverImage != nil && *update.CoverImage != "" {
+		media := []db.Media{{Data: *update.CoverImage}}
+		if err := saveMedia(media, "group-cover"); err != nil {
+			if errors.Is(err, errInvalidMedia) {
+				http.Error(w, "Invalid image", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Failed to save cover image", http.StatusInternalServerError)
+			log.Printf("Error saving group cover image: %v", err)
+			return
+		}
+		update.CoverImage = &media[0].URL
+	}
+
+	oldCover, err := db.UpdateGroup(groupID, update)
+	if err != nil {
+		if update.CoverImage != nil && *update.CoverImage != "" {
+			removeUploadedFile(*update.CoverImage)
+		}
+		http.Error(w, "Failed to update group", http.StatusInternalServerError)
+		log.Printf("Error updating group: %v", err)
+		return
+	}
+
+	if oldCover != nil {
+		if err := removeUploadedFile(*oldCover); err != nil {
+			log.Printf("Error removing group cover image: %v", err)
+		}
+	}
+
+	response := map[string]string{"message": "Group updated successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteGroupHandler lets admins and the owner delete a group with everything in it
+func DeleteGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/delete-group/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleAdmin) {
+		return
+	}
+
+	fileURLs, err := db.DeleteGroup(groupID)
+	if err != nil {
+		http.Error(w, "Failed to delete group", http.StatusInternalServerError)
+		log.Printf("Error deleting group: %v", err)
+		return
+	}
+
+	for _, url := range fileURLs {
+		if err := removeUploadedFile(url); err != nil {
+			log.Printf("Error removing group file: %v", err)
+		}
+	}
+
+	if err := removeGroupFiles(groupID); err != nil {
+		log.Printf("Error removing group file library: %v", err)
+	}
+
+	response := map[string]string{"message": "Group deleted successfully"}
+	json.NewEncoder(w).Encode(response)
+}
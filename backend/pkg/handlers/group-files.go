@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// groupFilesDir is where the files of a group are stored. It lies outside ./uploads so the files
+// can't be fetched without going through DownloadGroupFileHandler.
+func groupFilesDir(groupID int) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cwd, "group-files", strconv.Itoa(groupID)), nil
+}
+
+// removeGroupFiles deletes the stored files of a group, for when the group itself is deleted
+func removeGroupFiles(groupID int) error {
+	dirPath, err := groupFilesDir(groupID)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dirPath)
+}
+
+// UploadGroupFileHandler lets members add a document or an image to the file library of a group
+func UploadGroupFileHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/upload-group-file/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleMember) {
+		return
+	}
+
+	// Base64 takes four bytes for every three, the rest leaves room for the file name
+	r.Body = http.MaxBytesReader(w, r.Body, db.MaxGroupFileSize/3*4+64*1024)
+
+	var request db.UploadGroupFileRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	fileName, err := db.CleanGroupFileName(request.FileName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mimeType, content, err := decodeBase64Data(request.Data)
+	if err != nil {
+		http.Error(w, "Invalid file data", http.StatusBadRequest)
+		return
+	}
+
+	extension, ok := db.GroupFileExtension(mimeType)
+	if !ok {
+		http.Error(w, "Unsupported file type", http.StatusBadRequest)
+		return
+	}
+	if len(content) == 0 {
+		http.Error(w, "File is empty", http.StatusBadRequest)
+		return
+	}
+	if len(content) > db.MaxGroupFileSize {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	dirPath, err := groupFilesDir(groupID)
+	if err == nil {
+		err = os.MkdirAll(dirPath, 0755)
+	}
+	if err != nil {
+		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		log.Printf("Error creating group file directory: %v", err)
+		return
+	}
+
+	storedName := generateUniqueFileName(extension)
+	filePath := filepath.Join(dirPath, storedName)
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		log.Printf("Error writing group file: %v", err)
+		return
+	}
+
+	fileID, err := db.InsertGroupFile(db.GroupFile{
+		GroupID:    groupID,
+		UserID:     userID,
+		FileName:   fileName,
+		StoredName: storedName,
+		MimeType:   mimeType,
+		Size:       int64(len(content)),
+	})
+	if err != nil {
+		os.Remove(filePath)
+		if err == db.ErrGroupQuotaExceeded {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		log.Printf("Error inserting group file: %v", err)
+		return
+	}
+
+	response := map[string]interface{}{"message": "File uploaded successfully", "file_id": fileID}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetGroupFilesHandler lists the file library of a group to its members
+func GetGroupFilesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-files/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleMember) {
+		return
+	}
+
+	limit, offset := paginationFromQuery(r, 50, 200)
+
+	library, err := db.GetGroupFileLibrary(groupID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch group files", http.StatusInternalServerError)
+		log.Printf("Error fetching group files: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(library); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DownloadGroupFileHandler sends a file of a group library to a member as an attachment
+func DownloadGroupFileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	fileIDStr := r.URL.Path[len("/api/download-group-file/"):]
+	fileID, err := strconv.Atoi(fileIDStr)
+	if err != nil {
+		http.Error(w, "Invalid fileID", http.StatusBadRequest)
+		return
+	}
+
+	file, err := db.GetGroupFile(fileID)
+	if err != nil {
+		if err == db.ErrGroupFileNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch file", http.StatusInternalServerError)
+		return
+	}
+
+	if !checkGroupRole(w, file.GroupID, userID, db.GroupRoleMember) {
+		return
+	}
+
+	dirPath, err := groupFilesDir(file.GroupID)
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+
+	stored, err := os.Open(filepath.Join(dirPath, file.StoredName))
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		log.Printf("Error opening group file %d: %v", file.FileID, err)
+		return
+	}
+	defer stored.Close()
+
+	// Files are always downloaded, never rendered by the browser in the page's origin
+	w.Header().Set("Content-Type", file.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, file.FileName, file.CreatedAt, stored)
+}
+
+// DeleteGroupFileHandler lets the uploader, while still a member, or the admins of the group remove a file
+func DeleteGroupFileHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	fileIDStr := r.URL.Path[len("/api/delete-group-file/"):]
+	fileID, err := strconv.Atoi(fileIDStr)
+	if err != nil {
+		http.Error(w, "Invalid fileID", http.StatusBadRequest)
+		return
+	}
+
+	file, err := db.GetGroupFile(fileID)
+	if err != nil {
+		if err == db.ErrGroupFileNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch file", http.StatusInternalServerError)
+		return
+	}
+
+	minRole := db.GroupRoleAdmin
+	if file.UserID == userID {
+		minRole = db.GroupRoleMember
+	}
+	if !checkGroupRole(w, file.GroupID, userID, minRole) {
+		return
+	}
+
+	err = db.DeleteGroupFile(fileID)
+	if err != nil {
+		if err == db.ErrGroupFileNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete file", http.StatusInternalServerError)
+		return
+	}
+
+	dirPath, err := groupFilesDir(file.GroupID)
+	if err == nil {
+		err = os.Remove(filepath.Join(dirPath, file.StoredName))
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Error removing group file %d: %v", file.FileID, err)
+	}
+
+	response := map[string]string{"message": "File deleted successfully"}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// CreateGroupInviteLinkHandler lets admins create an invite link, optionally limited in time and uses
+func CreateGroupInviteLinkHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/create-group-invite-link/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.CreateInviteLinkRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := db.ValidateInviteLinkRequest(request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleAdmin) {
+		return
+	}
+
+	linkID, token, err := db.CreateGroupInviteLink(groupID, userID, request)
+	if err != nil {
+		http.Error(w, "Failed to create invite link", http.StatusInternalServerError)
+		log.Printf("Error creating group invite link: %v", err)
+		return
+	}
+
+	response := map[string]interface{}{"message": "Invite link created successfully", "link_id": linkID, "token": token}
+	json.NewEncoder(w).Encode(response)
+}
+
+func GetGroupInviteLinksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-invite-links/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleAdmin) {
+		return
+	}
+
+	links, err := db.GetGroupInviteLinks(groupID)
+	if err != nil {
+		http.Error(w, "Failed to fetch invite links", http.StatusInternalServerError)
+		log.Printf("Error fetching group invite links: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(links); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func RevokeGroupInviteLinkHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/revoke-group-invite-link/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.RevokeInviteLinkRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleAdmin) {
+		return
+	}
+
+	err = db.RevokeGroupInviteLink(groupID, request.LinkID)
+	if err != nil {
+		if err == db.ErrInviteLinkNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke invite link", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Invite link revoked successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// JoinGroupByInviteHandler adds the user to the group of the invite link without a join request
+func JoinGroupByInviteHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	token := r.URL.Path[len("/api/join-group-by-invite/"):]
+	if token == "" {
+		http.Error(w, "Invalid invite token", http.StatusBadRequest)
+		return
+	}
+
+	groupID, err := db.JoinGroupByInviteLink(token, userID)
+	if err != nil {
+		switch err {
+		case db.ErrInviteLinkNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case db.ErrInviteLinkInvalid:
+			http.Error(w, err.Error(), http.StatusGone)
+		case db.ErrUserBanned:
+			http.Error(w, "You are banned from this group", http.StatusForbidden)
+		case db.ErrAlreadyGroupMember:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to join group", http.StatusInternalServerError)
+			log.Printf("Error joining group by invite link: %v", err)
+		}
+		return
+	}
+
+	response := map[string]interface{}{"message": "Successfully joined the group", "group_id": groupID}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetGroupInviteLinkUsesHandler shows who joined the group through which invite link
+func GetGroupInviteLinkUsesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-invite-link-uses/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleAdmin) {
+		return
+	}
+
+	limit, offset := paginationFromQuery(r, 50, 200)
+
+	uses, err := db.GetGroupInviteLinkUses(groupID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch invite link uses", http.StatusInternalServerError)
+		log.Printf("Error fetching group invite link uses: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(uses); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GetGroupMembersHandler lists the members of a group to those who may read the group. Join
+// requests and invitations, picked with the status query parameter, and their counts are for
+// moderators only.
+func GetGroupMembersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-members/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "accepted"
+	}
+	if !db.ValidMemberStatus(status) {
+		http.Error(w, "Invalid member status", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupReadable(w, groupID, userID) {
+		return
+	}
+
+	isModerator, err := db.HasGroupRole(groupID, userID, db.GroupRoleModerator)
+	if err != nil {
+		http.Error(w, "Failed to fetch group role", http.StatusInternalServerError)
+		log.Printf("Error checking group role: %v", err)
+		return
+	}
+
+	if status != "accepted" && !isModerator {
+		http.Error(w, db.ErrGroupPermission.Error(), http.StatusForbidden)
+		return
+	}
+
+	limit, offset := paginationFromQuery(r, 50, 200)
+
+	directory, err := db.GetGroupMemberDirectory(groupID, status, isModerator, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch group members", http.StatusInternalServerError)
+		log.Printf("Error fetching group members: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(directory); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// UpdateGroupPostSettingsHandler lets admins decide whether new member posts need approval
+func UpdateGroupPostSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-post-settings/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var settings db.GroupPostSettingsRequest
+	err = json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleAdmin) {
+		return
+	}
+
+	err = db.SetGroupPostApproval(groupID, settings.PostsRequireApproval)
+	if err != nil {
+		http.Error(w, "Failed to update group settings", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Group settings updated successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPendingGroupPostsHandler lists the posts waiting for approval in a group for its moderators
+func GetPendingGroupPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-pending-posts/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	limit, offset := paginationFromQuery(r, 20, 100)
+
+	posts, err := db.GetPendingGroupPosts(groupID, userID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch pending posts", http.StatusInternalServerError)
+		log.Printf("Error fetching pending group posts: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(posts); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func ApproveGroupPostHandler(w http.ResponseWriter, r *http.Request) {
+	reviewGroupPost(w, r, "/api/approve-group-post/", true)
+}
+
+// RejectGroupPostHandler keeps a pending post out of the group for good
+func RejectGroupPostHandler(w http.ResponseWriter, r *http.Request) {
+	reviewGroupPost(w, r, "/api/reject-group-post/", false)
+}
+
+// reviewGroupPost approves or rejects the pending post named in the request body, moderators and above may do so
+func reviewGroupPost(w http.ResponseWriter, r *http.Request, pathPrefix string, approved bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len(pathPrefix):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.ReviewGroupPostRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	published, err := db.ReviewGroupPost(groupID, request.PostID, userID, approved)
+	if err != nil {
+		if err == db.ErrPostNotPending {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to review post", http.StatusInternalServerError)
+		log.Printf("Error reviewing group post: %v", err)
+		return
+	}
+
+	// Mentions go out once the post can be seen
+	if approved && published {
+		sendPublishedPostNotifications(request.PostID)
+	}
+
+	message := "Post rejected successfully"
+	if approved {
+		message = "Post approved successfully"
+	}
+
+	response := map[string]string{"message": message}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GetGroupRequestsHandler lists the open join requests and invitations of a group for its moderators
+func GetGroupRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-requests/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	pending, err := db.GetPendingGroupMembers(groupID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group requests", http.StatusInternalServerError)
+		log.Printf("Error fetching group requests: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pending); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ReviewGroupRequestsHandler accepts or rejects several join requests at once
+func ReviewGroupRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/review-group-requests/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.ReviewJoinRequestsRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.UserIDs) == 0 {
+		http.Error(w, "No users given", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleModerator) {
+		return
+	}
+
+	reviewed, err := db.ReviewJoinRequests(groupID, request.UserIDs, request.Accepted)
+	if err != nil {
+		http.Error(w, "Failed to review join requests", http.StatusInternalServerError)
+		log.Printf("Error reviewing join requests: %v", err)
+		return
+	}
+
+	response := map[string]interface{}{"message": "Join requests reviewed successfully", "reviewed": reviewed}
+	json.NewEncoder(w).Encode(response)
+}
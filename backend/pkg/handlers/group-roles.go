@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GetGroupRoleHandler returns the user's role in a group, empty if they are not a member
+func GetGroupRoleHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/group-role/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	role, err := db.GetGroupRole(groupID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group role", http.StatusInternalServerError)
+		log.Printf("Error fetching group role: %v", err)
+		return
+	}
+
+	response := map[string]string{"role": role}
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetGroupRoleHandler promotes or demotes a member. The acting user must outrank both
+// the member's current role and the new one, so admins manage moderators and members
+// and only the owner manages admins.
+func SetGroupRoleHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/set-group-role/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.GroupRoleRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !db.ValidAssignableRole(request.Role) {
+		http.Error(w, db.ErrInvalidRole.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actorRole, err := db.GetGroupRole(groupID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group role", http.StatusInternalServerError)
+		return
+	}
+
+	targetRole, err := db.GetGroupRole(groupID, request.UserID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group role", http.StatusInternalServerError)
+		return
+	}
+	if targetRole == "" {
+		http.Error(w, db.ErrNotGroupMember.Error(), http.StatusNotFound)
+		return
+	}
+
+	if !db.GroupRoleOutranks(actorRole, targetRole) || !db.GroupRoleOutranks(actorRole, request.Role) {
+		http.Error(w, db.ErrGroupPermission.Error(), http.StatusForbidden)
+		return
+	}
+
+	err = db.SetGroupMemberRole(groupID, request.UserID, request.Role)
+	if err != nil {
+		if err == db.ErrNotGroupMember {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update group role", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Group role updated successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// TransferGroupOwnershipHandler hands the group over to another member, the owner stays on as an admin
+func TransferGroupOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	groupIDStr := r.URL.Path[len("/api/transfer-group-ownership/"):]
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		http.Error(w, "Invalid groupID", http.StatusBadRequest)
+		return
+	}
+
+	var request db.GroupRoleRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleOwner) {
+		return
+	}
+
+	if request.UserID == userID {
+		http.Error(w, "You already own the group", http.StatusBadRequest)
+		return
+	}
+
+	err = db.TransferGroupOwnership(groupID, request.UserID)
+	if err != nil {
+		if err == db.ErrNotGroupMember {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to transfer group ownership", http.StatusInternalServerError)
+		log.Printf("Error transferring group ownership: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Group ownership transferred successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkGroupRole writes an error response and returns false unless the user is an accepted
+// member of the group with at least the given role
+func checkGroupRole(w http.ResponseWriter, groupID, userID int, minRole string) bool {
+	allowed, err := db.HasGroupRole(groupID, userID, minRole)
+	if err != nil {
+		http.Error(w, "Failed to fetch group role", http.StatusInternalServerError)
+		log.Printf("Error checking group role: %v", err)
+		return false
+	}
+
+	if !allowed {
+		http.Error(w, db.ErrGroupPermission.Error(), http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
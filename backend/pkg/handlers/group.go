@@ -3,9 +3,10 @@ package handlers
 import (
 	"backend/pkg/db"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -32,6 +33,16 @@ func CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if groupData.Visibility != "" && !db.ValidGroupVisibility(groupData.Visibility) {
+		http.Error(w, "Invalid group visibility", http.StatusBadRequest)
+		return
+	}
+
+	if len(groupData.Members) > db.MaxGroupInviteBatch {
+		http.Error(w, fmt.Sprintf("Invite at most %d users at once", db.MaxGroupInviteBatch), http.StatusBadRequest)
+		return
+	}
+
 	groupID, err := db.InsertGroup(groupData)
 	if err != nil {
 		http.Error(w, "Failed to insert group data", http.StatusInternalServerError)
@@ -39,31 +50,14 @@ func CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Insert the creator as a member with status 'accepted'
-	err = db.InsertGroupMember(groupID, userID, "accepted")
+	// The group exists even if inviting fails, the creator can invite the members again
+	_, err = db.InviteUsersToGroup(groupID, userID, groupData.Members)
 	if err != nil {
-		http.Error(w, "Failed to insert creator data", http.StatusInternalServerError)
-		log.Printf("Failed to insert creator data: %v", err)
+		http.Error(w, "Failed to invite members", http.StatusInternalServerError)
+		log.Printf("Failed to invite members: %v", err)
 		return
 	}
 
-	// Insert other selected members with status 'invited'
-	for _, memberID := range groupData.Members {
-
-		err = db.InsertGroupMember(groupID, memberID, "invited")
-		if err != nil {
-			http.Error(w, "Failed to insert member data", http.StatusInternalServerError)
-			log.Printf("Failed to insert member data: %v", err)
-			return
-		}
-
-		err = db.CreateGroupInvitationNotification(userID, memberID, groupID)
-		if err != nil {
-			log.Printf("Error creating group invitation notification: %v", err)
-			return
-		}
-	}
-
 	response := map[string]string{"message": "Group created successfully, and members are sent a notification to join to group"}
 	json.NewEncoder(w).Encode(response)
 }
@@ -97,28 +91,26 @@ func InviteToGroupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Now you can access the selectedMembers array from inviteReq
-	selectedMembers := inviteReq.SelectedMembers
-
-	// Insert other selected members with status 'invited'
-	for _, memberID := range selectedMembers {
+	if len(inviteReq.SelectedMembers) == 0 || len(inviteReq.SelectedMembers) > db.MaxGroupInviteBatch {
+		http.Error(w, fmt.Sprintf("Invite between 1 and %d users at once", db.MaxGroupInviteBatch), http.StatusBadRequest)
+		return
+	}
 
-		err = db.InsertGroupMember(groupID, memberID, "invited")
-		if err != nil {
-			http.Error(w, "Failed to insert member data", http.StatusInternalServerError)
-			log.Printf("Failed to insert member data: %v", err)
-			return
-		}
+	// Any member can invite others on purpose: invited users still have to accept, and banned users
+	// are skipped. Invite links, which let anyone in, are kept to the admins.
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleMember) {
+		return
+	}
 
-		err = db.CreateGroupInvitationNotification(userID, memberID, groupID)
-		if err != nil {
-			log.Printf("Error creating group invitation notification: %v", err)
-			return
-		}
+	result, err := db.InviteUsersToGroup(groupID, userID, inviteReq.SelectedMembers)
+	if err != nil {
+		http.Error(w, "Failed to invite users", http.StatusInternalServerError)
+		log.Printf("Failed to invite users: %v", err)
+		return
 	}
 
 	// Send success response
-	response := map[string]string{"message": "Users invited successfully"}
+	response := map[string]interface{}{"message": "Users invited successfully", "invited": result.Invited, "skipped": result.Skipped}
 	json.NewEncoder(w).Encode(response)
 
 }
@@ -146,13 +138,28 @@ func JoinGroupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = db.JoinGroup(userID, groupID)
+	joined, err := db.JoinGroup(userID, groupID)
+	if err == db.ErrUserBanned {
+		http.Error(w, "You are banned from this group", http.StatusForbidden)
+		return
+	}
+	if err == db.ErrGroupNotFound {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to join group", http.StatusInternalServerError)
 		log.Printf("Error joining group: %v", err)
 		return
 	}
 
+	// Public groups and invitations to secret groups let the user in without a request
+	if joined {
+		response := map[string]string{"message": "Successfully joined the group"}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
 	err = db.CreateJoinGroupRequestNotification(userID, groupID)
 	if err != nil {
 		log.Printf("Error creating join group invitation notification: %v", err)
@@ -190,6 +197,10 @@ func LeaveGroupHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = db.LeaveGroup(userID, groupID)
+	if err == db.ErrLastGroupOwner {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to leave group", http.StatusInternalServerError)
 		log.Printf("Error leaving group: %v", err)
@@ -240,7 +251,10 @@ func GetAllGroupsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	groups, err := db.GetAllGroups()
+	// Visitors who aren't logged in only see the groups that aren't secret
+	userID := userIDFromSession(r)
+
+	groups, err := db.GetAllGroups(userID)
 	if err != nil {
 		http.Error(w, "GetAllGroups: Failed to fetch groups", http.StatusInternalServerError)
 		log.Printf("GetAllGroups: Failed to fetch groups %v", err)
@@ -267,8 +281,18 @@ func GetGroupPostsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	if !checkGroupReadable(w, groupID, userID) {
+		return
+	}
+
 	// Call your DB function to fetch the post by its ID
-	post, err := db.GetPostsByGroupID(groupID)
+	post, err := db.GetPostsByGroupID(groupID, userID)
 	if err != nil {
 		http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
 		return
@@ -296,6 +320,17 @@ func GetGroupInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Secret groups are only shown to their members and invited users
+	visible, err := db.CanSeeGroup(groupID, userIDFromSession(r))
+	if err != nil {
+		http.Error(w, "Failed to fetch group", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
 	// Call your DB function to fetch the post by its ID
 	info, err := db.GetGroupByID(groupID)
 	if err != nil {
@@ -303,6 +338,16 @@ func GetGroupInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Like the member directory, the members of closed and secret groups are only listed to members
+	readable, err := db.CanReadGroupContent(groupID, userIDFromSession(r))
+	if err != nil {
+		http.Error(w, "Failed to fetch group", http.StatusInternalServerError)
+		return
+	}
+	if !readable {
+		info.Members = nil
+	}
+
 	// Serialize the post to JSON and send it in the response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(info); err != nil {
@@ -368,6 +413,11 @@ func CreateGroupPostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only members post in a group
+	if !checkGroupRole(w, groupID, userID, db.GroupRoleMember) {
+		return
+	}
+
 	var groupPostData db.GroupPost
 	err = json.NewDecoder(r.Body).Decode(&groupPostData)
 	if err != nil {
@@ -378,31 +428,106 @@ func CreateGroupPostHandler(w http.ResponseWriter, r *http.Request) {
 	groupPostData.UserID = userID
 	groupPostData.GroupID = groupID
 
-	if groupPostData.PostImage != nil && *groupPostData.PostImage != "" {
+	needsApproval, err := db.GroupPostNeedsApproval(groupID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check group settings", http.StatusInternalServerError)
+		log.Printf("Failed to check group post approval: %v", err)
+		return
+	}
+	groupPostData.ApprovalStatus = db.ApprovalApproved
+	if needsApproval {
+		groupPostData.ApprovalStatus = db.ApprovalPending
+	}
+
+	if groupPostData.CommentPolicy != "" && !db.ValidCommentPolicy(groupPostData.CommentPolicy) {
+		http.Error(w, "Invalid comment policy", http.StatusBadRequest)
+		return
+	}
+
+	groupPostData.Status, groupPostData.PublishAt, err = db.ValidatePublishing(groupPostData.Status, groupPostData.PublishAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		cwd, err := os.Getwd()
-		if err != nil {
-			log.Fatalf("Post: Error getting current working directory: %v", err)
+	if groupPostData.Poll != nil {
+		if err := db.ValidatePoll(groupPostData.Poll); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
+	}
 
-		dirPath := cwd + "/uploads/post-image"
+	// Clients that only send post_image get it as the single media item
+	if len(groupPostData.Media) == 0 && groupPostData.PostImage != nil && *groupPostData.PostImage != "" {
+		groupPostData.Media = []db.Media{{Data: *groupPostData.PostImage}}
+	}
+
+	if err := db.ValidateMedia(groupPostData.Media, db.MaxPostMedia); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		fileName, err := saveBase64File(*groupPostData.PostImage, dirPath)
-		if err != nil {
-			http.Error(w, "Post: Failed to save post image", http.StatusInternalServerError)
+	err = saveMedia(groupPostData.Media, "post-image")
+	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			http.Error(w, "Invalid image", http.StatusBadRequest)
 			return
 		}
-		webPath := "http://localhost:8000/uploads/post-image/" + fileName
-		groupPostData.PostImage = &webPath
+		http.Error(w, "Post: Failed to save post image", http.StatusInternalServerError)
+		log.Printf("Failed to save media: %v", err)
+		return
 	}
 
-	err = db.InsertGroupPost(groupPostData)
+	groupPostData.PostImage = nil
+	if len(groupPostData.Media) > 0 {
+		groupPostData.PostImage = &groupPostData.Media[0].URL
+	}
+
+	postID, err := db.InsertGroupPost(groupPostData)
 	if err != nil {
 		http.Error(w, "Failed to insert post data", http.StatusInternalServerError)
 		log.Printf("Failed to insert post data: %v", err)
 		return
 	}
 
+	if groupPostData.ApprovalStatus == db.ApprovalPending {
+		response := map[string]string{"message": "Post submitted for approval"}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if groupPostData.Status == db.PostPublished {
+		sendPublishedPostNotifications(postID)
+	}
+
 	response := map[string]string{"message": "Post created successfully"}
 	json.NewEncoder(w).Encode(response)
 }
+
+// checkGroupReadable writes an error response and returns false unless the user may read the
+// posts and events of the group. Secret groups are reported as missing to those who can't see them.
+func checkGroupReadable(w http.ResponseWriter, groupID, userID int) bool {
+	visible, err := db.CanSeeGroup(groupID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group", http.StatusInternalServerError)
+		log.Printf("Error checking group visibility: %v", err)
+		return false
+	}
+	if !visible {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return false
+	}
+
+	readable, err := db.CanReadGroupContent(groupID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch group", http.StatusInternalServerError)
+		log.Printf("Error checking group access: %v", err)
+		return false
+	}
+	if !readable {
+		http.Error(w, "Only members can see the content of this group", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
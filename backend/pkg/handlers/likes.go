@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func LikePostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the dynamic part of the URL
+	postIDStr := r.URL.Path[len("/api/like-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkPostVisible(w, postID, userID) {
+		return
+	}
+
+	isNew, err := db.LikePost(postID, userID)
+	if err != nil {
+		http.Error(w, "Failed to like post", http.StatusInternalServerError)
+		log.Printf("Error liking post: %v", err)
+		return
+	}
+
+	if isNew {
+		err = db.CreatePostLikeNotification(userID, postID)
+		if err != nil {
+			log.Printf("Error creating post like notification: %v", err)
+		}
+	}
+
+	response := map[string]string{"message": "Post liked successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func UnlikePostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/unlike-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	err = db.UnlikePost(postID, userID)
+	if err != nil {
+		http.Error(w, "Failed to unlike post", http.StatusInternalServerError)
+		log.Printf("Error unliking post: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Post unliked successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func LikeCommentHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	commentIDStr := r.URL.Path[len("/api/like-comment/"):]
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid commentID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkCommentVisible(w, commentID, userID) {
+		return
+	}
+
+	isNew, err := db.LikeComment(commentID, userID)
+	if err != nil {
+		http.Error(w, "Failed to like comment", http.StatusInternalServerError)
+		log.Printf("Error liking comment: %v", err)
+		return
+	}
+
+	if isNew {
+		err = db.CreateCommentLikeNotification(userID, commentID)
+		if err != nil {
+			log.Printf("Error creating comment like notification: %v", err)
+		}
+	}
+
+	response := map[string]string{"message": "Comment liked successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func UnlikeCommentHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	commentIDStr := r.URL.Path[len("/api/unlike-comment/"):]
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid commentID", http.StatusBadRequest)
+		return
+	}
+
+	err = db.UnlikeComment(commentID, userID)
+	if err != nil {
+		http.Error(w, "Failed to unlike comment", http.StatusInternalServerError)
+		log.Printf("Error unliking comment: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Comment unliked successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func GetPostLikesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/get-post-likes/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkPostVisible(w, postID, userID) {
+		return
+	}
+
+	likes, err := db.GetPostLikers(postID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch likes", http.StatusInternalServerError)
+		log.Printf("Error fetching post likes: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(likes); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetCommentLikesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	commentIDStr := r.URL.Path[len("/api/get-comment-likes/"):]
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid commentID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkCommentVisible(w, commentID, userID) {
+		return
+	}
+
+	likes, err := db.GetCommentLikers(commentID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch likes", http.StatusInternalServerError)
+		log.Printf("Error fetching comment likes: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(likes); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func PinPostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/pin-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkPinPermission(w, postID, userID) {
+		return
+	}
+
+	err = db.PinPost(postID)
+	if err != nil {
+		switch err {
+		case db.ErrPostNotFound:
+			http.Error(w, "Post not found", http.StatusNotFound)
+		case db.ErrPinLimitReached:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to pin post", http.StatusInternalServerError)
+			log.Printf("Error pinning post: %v", err)
+		}
+		return
+	}
+
+	response := map[string]string{"message": "Post pinned successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func UnpinPostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/unpin-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkPinPermission(w, postID, userID) {
+		return
+	}
+
+	err = db.UnpinPost(postID)
+	if err != nil {
+		http.Error(w, "Failed to unpin post", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Post unpinned successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkPinPermission writes an error response and returns false unless the user may pin the post:
+// group posts are pinned by the group admins, other posts by their author
+func checkPinPermission(w http.ResponseWriter, postID, userID int) bool {
+	authorID, groupID, err := db.GetPinTarget(postID)
+	if err != nil {
+		if err == db.ErrPostNotFound {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return false
+		}
+		http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
+		return false
+	}
+
+	if groupID != nil {
+		isAdmin, err := db.IsGroupAdmin(*groupID, userID)
+		if err != nil {
+			http.Error(w, "Failed to check group admin", http.StatusInternalServerError)
+			return false
+		}
+		if !isAdmin {
+			http.Error(w, "Only group admins can pin group posts", http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+
+	if authorID != userID {
+		http.Error(w, "Only the author can pin the post", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
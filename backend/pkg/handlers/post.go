@@ -3,9 +3,9 @@ package handlers
 import (
 	"backend/pkg/db"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -32,22 +32,48 @@ func CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 
 	postData.UserID = userID
 
-	if postData.PostImage != nil && *postData.PostImage != "" {
+	if postData.CommentPolicy != "" && !db.ValidCommentPolicy(postData.CommentPolicy) {
+		http.Error(w, "Invalid comment policy", http.StatusBadRequest)
+		return
+	}
 
-		cwd, err := os.Getwd()
-		if err != nil {
-			log.Fatalf("Post: Error getting current working directory: %v", err)
+	postData.Status, postData.PublishAt, err = db.ValidatePublishing(postData.Status, postData.PublishAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if postData.Poll != nil {
+		if err := db.ValidatePoll(postData.Poll); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
+	}
 
-		dirPath := cwd + "/uploads/post-image"
+	// Clients that only send post_image get it as the single media item
+	if len(postData.Media) == 0 && postData.PostImage != nil && *postData.PostImage != "" {
+		postData.Media = []db.Media{{Data: *postData.PostImage}}
+	}
 
-		fileName, err := saveBase64File(*postData.PostImage, dirPath)
-		if err != nil {
-			http.Error(w, "Post: Failed to save post image", http.StatusInternalServerError)
+	if err := db.ValidateMedia(postData.Media, db.MaxPostMedia); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = saveMedia(postData.Media, "post-image")
+	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			http.Error(w, "Invalid image", http.StatusBadRequest)
 			return
 		}
-		webPath := "http://localhost:8000/uploads/post-image/" + fileName
-		postData.PostImage = &webPath
+		http.Error(w, "Post: Failed to save post image", http.StatusInternalServerError)
+		log.Printf("Failed to save media: %v", err)
+		return
+	}
+
+	postData.PostImage = nil
+	if len(postData.Media) > 0 {
+		postData.PostImage = &postData.Media[0].URL
 	}
 
 	postID, err := db.InsertPost(postData)
@@ -66,6 +92,12 @@ func CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Mentions are checked after the viewers are stored, as they decide who can see the post.
+	// Drafts and scheduled posts notify when they are published.
+	if postData.Status == db.PostPublished {
+		sendPublishedPostNotifications(postID)
+	}
+
 	response := map[string]string{"message": "Post created successfully"}
 	json.NewEncoder(w).Encode(response)
 }
@@ -169,8 +201,29 @@ func GetPostFromPostID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	authorID, err := db.GetPostAuthor(postID)
+	if err != nil {
+		if err == db.ErrPostNotFound {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
+		return
+	}
+
+	// Authors also see their drafts, scheduled posts and group posts that weren't approved
+	if authorID != userID && !checkPostVisible(w, postID, userID) {
+		return
+	}
+
 	// Call your DB function to fetch the post by its ID
-	post, err := db.GetPostByPostID(postID)
+	post, err := db.GetPostByPostID(postID, userID)
 	if err != nil {
 		http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
 		return
@@ -218,3 +271,283 @@ func ViewerStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func UpdateCommentSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/update-comment-settings/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	var settings db.CommentSettings
+	err = json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !db.ValidCommentPolicy(settings.CommentPolicy) {
+		http.Error(w, "Invalid comment policy", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetPostByPostID(postID, userID)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	if post.UserID != userID {
+		http.Error(w, "Only the author can change the comment settings", http.StatusForbidden)
+		return
+	}
+
+	err = db.UpdateCommentSettings(postID, settings)
+	if err != nil {
+		http.Error(w, "Failed to update comment settings", http.StatusInternalServerError)
+		log.Printf("Failed to update comment settings: %v", err)
+		return
+	}
+
+	response := map[string]string{"message": "Comment settings updated successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkPostVisible writes an error response and returns false if the user may not see the post
+func checkPostVisible(w http.ResponseWriter, postID, userID int) bool {
+	visible, err := db.CanViewPost(postID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check post visibility", http.StatusInternalServerError)
+		return false
+	}
+
+	if !visible {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return false
+	}
+
+	return true
+}
+
+// ResharePostHandler shares a visible post to the user's own audience, optionally with a quote
+func ResharePostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/reshare-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	var reshare db.Post
+	err = json.NewDecoder(r.Body).Decode(&reshare)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if reshare.PrivacyLevel == nil || !db.ValidPrivacyLevel(*reshare.PrivacyLevel) {
+		http.Error(w, "Invalid privacy level", http.StatusBadRequest)
+		return
+	}
+
+	if reshare.CommentPolicy != "" && !db.ValidCommentPolicy(reshare.CommentPolicy) {
+		http.Error(w, "Invalid comment policy", http.StatusBadRequest)
+		return
+	}
+
+	targetID, err := db.ReshareTarget(postID, userID, *reshare.PrivacyLevel)
+	if err != nil {
+		switch err {
+		case db.ErrPostNotFound:
+			http.Error(w, "Post not found", http.StatusNotFound)
+		case db.ErrReshareGroupPost, db.ErrReshareBroadensAudience:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to reshare post", http.StatusInternalServerError)
+			log.Printf("Error checking reshare target: %v", err)
+		}
+		return
+	}
+
+	reshare.UserID = userID
+	reshare.GroupID = nil
+	reshare.PostImage = nil
+	reshare.Media = nil
+	reshare.Poll = nil
+	reshare.Status = db.PostPublished
+	reshare.PublishAt = nil
+	reshare.ResharedPostID = &targetID
+
+	reshareID, err := db.InsertPost(reshare)
+	if err != nil {
+		http.Error(w, "Failed to insert post data", http.StatusInternalServerError)
+		log.Printf("Failed to insert reshare: %v", err)
+		return
+	}
+
+	if len(reshare.ViewerIDs) > 0 {
+		err = db.InsertPostViewers(reshareID, reshare.ViewerIDs)
+		if err != nil {
+			http.Error(w, "Failed to insert post viewers", http.StatusInternalServerError)
+			log.Printf("Failed to insert post viewers: %v", err)
+			return
+		}
+	}
+
+	err = db.CreatePostReshareNotification(userID, reshareID)
+	if err != nil {
+		log.Printf("Error creating post reshare notification: %v", err)
+	}
+
+	err = db.CreatePostMentionNotifications(reshareID)
+	if err != nil {
+		log.Printf("Error creating post mention notifications: %v", err)
+	}
+
+	response := map[string]string{"message": "Post reshared successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func DeletePostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/delete-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetPostByPostID(postID, userID)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Group posts can also be deleted by the group moderators and above
+	if post.UserID != userID {
+		if post.GroupID == nil {
+			http.Error(w, "Only the author can delete the post", http.StatusForbidden)
+			return
+		}
+		if !checkGroupRole(w, *post.GroupID, userID, db.GroupRoleModerator) {
+			return
+		}
+	}
+
+	fileURLs, err := db.DeletePost(postID)
+	if err != nil {
+		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+		log.Printf("Failed to delete post: %v", err)
+		return
+	}
+
+	for _, url := range fileURLs {
+		if err := removeUploadedFile(url); err != nil {
+			log.Printf("Error removing post image: %v", err)
+		}
+	}
+
+	response := map[string]string{"message": "Post deleted successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// VotePollHandler replaces the user's votes on the poll of a post
+func VotePollHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/vote-poll/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	var vote db.PollVoteRequest
+	err = json.NewDecoder(r.Body).Decode(&vote)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if !checkPostVisible(w, postID, userID) {
+		return
+	}
+
+	err = db.VotePoll(postID, userID, vote.OptionIDs)
+	if err != nil {
+		switch err {
+		case db.ErrPollNotFound:
+			http.Error(w, "Poll not found", http.StatusNotFound)
+		case db.ErrPollClosed:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case db.ErrInvalidPollOption, db.ErrSingleChoicePoll:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to vote", http.StatusInternalServerError)
+			log.Printf("Error voting on poll: %v", err)
+		}
+		return
+	}
+
+	post, err := db.GetPostByPostID(postID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch poll results", http.StatusInternalServerError)
+		return
+	}
+
+	// The updated results are sent back so the frontend can show them right away
+	if err := json.NewEncoder(w).Encode(post.Poll); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
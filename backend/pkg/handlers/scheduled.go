@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StartPostScheduler publishes scheduled posts once their time has come, checking at every interval.
+// It is meant to run in its own goroutine for the lifetime of the server.
+func StartPostScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Posts that came due while the server was down are published right away
+	publishDuePosts()
+
+	for range ticker.C {
+		publishDuePosts()
+	}
+}
+
+func publishDuePosts() {
+	postIDs, err := db.GetDuePostIDs()
+	if err != nil {
+		log.Printf("Error fetching due posts: %v", err)
+		return
+	}
+
+	for _, postID := range postIDs {
+		published, err := db.PublishPost(postID)
+		if err != nil {
+			log.Printf("Error publishing scheduled post %d: %v", postID, err)
+			continue
+		}
+		if !published {
+			continue
+		}
+
+		err = db.CreatePostPublishedNotification(postID)
+		if err != nil {
+			log.Printf("Error creating post published notification: %v", err)
+		}
+
+		sendPublishedPostNotifications(postID)
+	}
+}
+
+// sendPublishedPostNotifications sends the notifications that go out when a post becomes visible
+func sendPublishedPostNotifications(postID int) {
+	err := db.CreatePostMentionNotifications(postID)
+	if err != nil {
+		log.Printf("Error creating post mention notifications: %v", err)
+	}
+}
+
+func GetScheduledPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	posts, err := db.GetScheduledPosts(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch posts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(posts); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// EditScheduledPostHandler changes the content and publishing state of a draft or scheduled post,
+// publishing it right away when the status is set to published. Group posts can only be changed
+// while the author is still a member of the group.
+func EditScheduledPostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/edit-scheduled-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	var update db.ScheduledPostUpdate
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	update.Status, update.PublishAt, err = db.ValidatePublishing(update.Status, update.PublishAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !checkUnpublishedPostAuthor(w, postID, userID) {
+		return
+	}
+
+	published, err := db.UpdateScheduledPost(postID, update)
+	if err != nil {
+		if err == db.ErrNotGroupMember {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		return
+	}
+
+	if published {
+		sendPublishedPostNotifications(postID)
+	}
+
+	response := map[string]string{"message": "Post updated successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func CancelScheduledPostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	postIDStr := r.URL.Path[len("/api/cancel-scheduled-post/"):]
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		http.Error(w, "Invalid postID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkUnpublishedPostAuthor(w, postID, userID) {
+		return
+	}
+
+	fileURLs, err := db.DeletePost(postID)
+	if err != nil {
+		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+		return
+	}
+
+	for _, url := range fileURLs {
+		if err := removeUploadedFile(url); err != nil {
+			log.Printf("Error removing post image: %v", err)
+		}
+	}
+
+	response := map[string]string{"message": "Scheduled post cancelled successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkUnpublishedPostAuthor writes an error response and returns false unless the post is a draft
+// or scheduled post of the user
+func checkUnpublishedPostAuthor(w http.ResponseWriter, postID, userID int) bool {
+	authorID, err := db.GetUnpublishedPostAuthor(postID)
+	if err == db.ErrPostNotFound || (err == nil && authorID != userID) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if db.BuildSearchQuery(text) == "" {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+
+	// type is a comma separated list, all result types are searched when it is left out
+	types := db.SearchTypes
+	if typeParam := r.URL.Query().Get("type"); typeParam != "" {
+		types = nil
+		for _, searchType := range strings.Split(typeParam, ",") {
+			searchType = strings.TrimSpace(searchType)
+			if !validSearchType(searchType) {
+				http.Error(w, "Invalid search type", http.StatusBadRequest)
+				return
+			}
+			types = append(types, searchType)
+		}
+	}
+
+	limit, offset := paginationFromQuery(r, 20, 100)
+
+	results, err := db.Search(text, types, userID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to search", http.StatusInternalServerError)
+		log.Printf("Error searching: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func validSearchType(searchType string) bool {
+	for _, t := range db.SearchTypes {
+		if t == searchType {
+			return true
+		}
+	}
+	return false
+}
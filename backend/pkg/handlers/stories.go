@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StartStoryCleanup deletes expired stories and their images at every interval.
+// It is meant to run in its own goroutine for the lifetime of the server.
+func StartStoryCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	deleteExpiredStories()
+
+	for range ticker.C {
+		deleteExpiredStories()
+	}
+}
+
+func deleteExpiredStories() {
+	imageURLs, err := db.DeleteExpiredStories()
+	if err != nil {
+		log.Printf("Error deleting expired stories: %v", err)
+		return
+	}
+
+	for _, imageURL := range imageURLs {
+		if err := removeUploadedFile(imageURL); err != nil {
+			log.Printf("Error removing story image: %v", err)
+		}
+	}
+}
+
+func CreateStoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	var story db.Story
+	err := json.NewDecoder(r.Body).Decode(&story)
+	if err != nil {
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := db.ValidateStory(&story); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if story.Media != nil {
+		media := []db.Media{*story.Media}
+		if err := saveMedia(media, "story-image"); err != nil {
+			if errors.Is(err, errInvalidMedia) {
+				http.Error(w, "Invalid image", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Failed to save image", http.StatusInternalServerError)
+			log.Printf("Error saving story image: %v", err)
+			return
+		}
+		story.Media = &media[0]
+	}
+
+	story.UserID = userID
+
+	storyID, err := db.InsertStory(story)
+	if err != nil {
+		if story.Media != nil {
+			removeUploadedFile(story.Media.URL)
+		}
+		http.Error(w, "Failed to create story", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{"message": "Story created successfully", "story_id": storyID}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetStoriesHandler lists the active stories of the user and of the people they may see stories from
+func GetStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	stories, err := db.GetStoriesFeed(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch stories", http.StatusInternalServerError)
+		log.Printf("Error fetching stories: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stories); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func GetUserStoriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	authorIDStr := r.URL.Path[len("/api/user-stories/"):]
+	authorID, err := strconv.Atoi(authorIDStr)
+	if err != nil {
+		http.Error(w, "Invalid userID", http.StatusBadRequest)
+		return
+	}
+
+	stories, err := db.GetUserStories(authorID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch stories", http.StatusInternalServerError)
+		log.Printf("Error fetching user stories: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stories); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func ViewStoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	storyIDStr := r.URL.Path[len("/api/view-story/"):]
+	storyID, err := strconv.Atoi(storyIDStr)
+	if err != nil {
+		http.Error(w, "Invalid storyID", http.StatusBadRequest)
+		return
+	}
+
+	visible, err := db.CanViewStory(storyID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch story", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		http.Error(w, "Story not found", http.StatusNotFound)
+		return
+	}
+
+	err = db.MarkStoryViewed(storyID, userID)
+	if err != nil {
+		http.Error(w, "Failed to mark story as viewed", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Story marked as viewed"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetStoryViewersHandler lists who has seen a story, only the author may see the list
+func GetStoryViewersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	storyIDStr := r.URL.Path[len("/api/story-viewers/"):]
+	storyID, err := strconv.Atoi(storyIDStr)
+	if err != nil {
+		http.Error(w, "Invalid storyID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkStoryAuthor(w, storyID, userID) {
+		return
+	}
+
+	viewers, err := db.GetStoryViewers(storyID)
+	if err != nil {
+		http.Error(w, "Failed to fetch viewers", http.StatusInternalServerError)
+		log.Printf("Error fetching story viewers: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(viewers); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func DeleteStoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	storyIDStr := r.URL.Path[len("/api/delete-story/"):]
+	storyID, err := strconv.Atoi(storyIDStr)
+	if err != nil {
+		http.Error(w, "Invalid storyID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkStoryAuthor(w, storyID, userID) {
+		return
+	}
+
+	imageURL, err := db.DeleteStory(storyID)
+	if err != nil {
+		http.Error(w, "Failed to delete story", http.StatusInternalServerError)
+		return
+	}
+
+	if imageURL != nil {
+		if err := removeUploadedFile(*imageURL); err != nil {
+			log.Printf("Error removing story image: %v", err)
+		}
+	}
+
+	response := map[string]string{"message": "Story deleted successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkStoryAuthor writes an error response and returns false unless the story is an active story of the user
+func checkStoryAuthor(w http.ResponseWriter, storyID, userID int) bool {
+	authorID, err := db.GetStoryAuthor(storyID)
+	if err != nil {
+		if err == db.ErrStoryNotFound {
+			http.Error(w, "Story not found", http.StatusNotFound)
+			return false
+		}
+		http.Error(w, "Failed to fetch story", http.StatusInternalServerError)
+		return false
+	}
+
+	if authorID != userID {
+		http.Error(w, "Only the author can do this", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
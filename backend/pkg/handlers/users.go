@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 func UserHandler(w http.ResponseWriter, r *http.Request) {
@@ -88,6 +89,43 @@ func getUsersData(w http.ResponseWriter) {
 	}
 }
 
+// UserDirectoryHandler lists the other users one page at a time, with the viewer's relationship to each.
+// q filters by name or nickname prefix, filter by relationship (followers, following, mutuals, not_following).
+func UserDirectoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	filter := r.URL.Query().Get("filter")
+	if !db.ValidDirectoryFilter(filter) {
+		http.Error(w, "Invalid filter", http.StatusBadRequest)
+		return
+	}
+
+	prefix := strings.TrimSpace(r.URL.Query().Get("q"))
+	limit, offset := paginationFromQuery(r, 20, 100)
+
+	directory, err := db.GetUserDirectory(userID, prefix, filter, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+		log.Printf("Error fetching user directory: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(directory); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 func UserIDHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
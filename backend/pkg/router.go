@@ -17,6 +17,10 @@ func SetupRouter() http.Handler {
 	mux.HandleFunc("/api/create-post/", handlers.CreatePostHandler)                      // gets data from form to create a new post and save in db
 	mux.HandleFunc("/api/add-comment/", handlers.CreateCommentHandler)                   // gets data from form to create a new comment and save in db
 	mux.HandleFunc("/api/get-comments-for-post/", handlers.GetCommentsFromPostIDHandler) // fetches comments for a post
+	mux.HandleFunc("/api/get-comment-replies/", handlers.GetCommentRepliesHandler)       // fetches one page of replies to a comment
+	mux.HandleFunc("/api/edit-comment/", handlers.EditCommentHandler)                    // lets the author change the content of a comment
+	mux.HandleFunc("/api/delete-comment/", handlers.DeleteCommentHandler)                // removes a comment, leaving a tombstone in the thread
+	mux.HandleFunc("/api/hide-comment/", handlers.HideCommentHandler)                    // hides or unhides a comment on a post the user moderates
 	mux.HandleFunc("/api/posts/", handlers.GetPostsFromSessionHandler)                   // fetches posts to display on the My profile based on ID from session
 	mux.HandleFunc("/api/postsFromID/", handlers.GetPostsFromIDHandler)                  // fetches posts to display on the Other users profile based on ID from front end sent in URL
 	mux.HandleFunc("/api/userid/", handlers.UserIDHandler)                               // displays user (profile) page based on id
@@ -24,6 +28,7 @@ func SetupRouter() http.Handler {
 	mux.HandleFunc("/api/get-users", handlers.UsersHandler)                              // fetches data about all users
 	mux.HandleFunc("/api/get-post/", handlers.GetPostFromPostID)                         // fetches single post based on postID from frontend
 
+	mux.HandleFunc("/api/user-directory", handlers.UserDirectoryHandler)
 	mux.HandleFunc("/api/get-follow-status/", handlers.GetFollowStatusHandler)
 	mux.HandleFunc("/api/follow-user/", handlers.FollowUserHandler)
 	mux.HandleFunc("/api/unfollow-user/", handlers.UnfollowUserHandler)
@@ -41,8 +46,69 @@ func SetupRouter() http.Handler {
 	mux.HandleFunc("/api/get-group-info/", handlers.GetGroupInfoHandler)
 	mux.HandleFunc("/api/check-membership/", handlers.CheckMembershipHandler)
 	mux.HandleFunc("/api/create-group-post/", handlers.CreateGroupPostHandler)
+	mux.HandleFunc("/api/group-role/", handlers.GetGroupRoleHandler)
+	mux.HandleFunc("/api/set-group-role/", handlers.SetGroupRoleHandler)
+	mux.HandleFunc("/api/transfer-group-ownership/", handlers.TransferGroupOwnershipHandler)
+	mux.HandleFunc("/api/group-requests/", handlers.GetGroupRequestsHandler)
+	mux.HandleFunc("/api/review-group-requests/", handlers.ReviewGroupRequestsHandler)
+	mux.HandleFunc("/api/remove-group-member/", handlers.RemoveGroupMemberHandler)
+	mux.HandleFunc("/api/ban-group-member/", handlers.BanGroupMemberHandler)
+	mux.HandleFunc("/api/unban-group-member/", handlers.UnbanGroupMemberHandler)
+	mux.HandleFunc("/api/group-bans/", handlers.GetGroupBansHandler)
+	mux.HandleFunc("/api/group-moderation-log/", handlers.GetGroupModerationLogHandler)
+	mux.HandleFunc("/api/update-group/", handlers.UpdateGroupHandler)
+	mux.HandleFunc("/api/delete-group/", handlers.DeleteGroupHandler)
+	mux.HandleFunc("/api/create-group-invite-link/", handlers.CreateGroupInviteLinkHandler)
+	mux.HandleFunc("/api/group-invite-links/", handlers.GetGroupInviteLinksHandler)
+	mux.HandleFunc("/api/revoke-group-invite-link/", handlers.RevokeGroupInviteLinkHandler)
+	mux.HandleFunc("/api/join-group-by-invite/", handlers.JoinGroupByInviteHandler)
+	mux.HandleFunc("/api/group-invite-link-uses/", handlers.GetGroupInviteLinkUsesHandler)
+	mux.HandleFunc("/api/group-members/", handlers.GetGroupMembersHandler)
+	mux.HandleFunc("/api/group-post-settings/", handlers.UpdateGroupPostSettingsHandler)
+	mux.HandleFunc("/api/group-pending-posts/", handlers.GetPendingGroupPostsHandler)
+	mux.HandleFunc("/api/approve-group-post/", handlers.ApproveGroupPostHandler)
+	mux.HandleFunc("/api/reject-group-post/", handlers.RejectGroupPostHandler)
+	mux.HandleFunc("/api/upload-group-file/", handlers.UploadGroupFileHandler)
+	mux.HandleFunc("/api/group-files/", handlers.GetGroupFilesHandler)
+	mux.HandleFunc("/api/download-group-file/", handlers.DownloadGroupFileHandler)
+	mux.HandleFunc("/api/delete-group-file/", handlers.DeleteGroupFileHandler)
 	mux.HandleFunc("/api/viewer-status/", handlers.ViewerStatusHandler)
 
+	mux.HandleFunc("/api/like-post/", handlers.LikePostHandler)
+	mux.HandleFunc("/api/unlike-post/", handlers.UnlikePostHandler)
+	mux.HandleFunc("/api/get-post-likes/", handlers.GetPostLikesHandler)
+	mux.HandleFunc("/api/like-comment/", handlers.LikeCommentHandler)
+	mux.HandleFunc("/api/unlike-comment/", handlers.UnlikeCommentHandler)
+	mux.HandleFunc("/api/get-comment-likes/", handlers.GetCommentLikesHandler)
+	mux.HandleFunc("/api/update-comment-settings/", handlers.UpdateCommentSettingsHandler)
+	mux.HandleFunc("/api/reshare-post/", handlers.ResharePostHandler)
+	mux.HandleFunc("/api/delete-post/", handlers.DeletePostHandler)
+	mux.HandleFunc("/api/vote-poll/", handlers.VotePollHandler)
+	mux.HandleFunc("/api/scheduled-posts", handlers.GetScheduledPostsHandler)
+	mux.HandleFunc("/api/edit-scheduled-post/", handlers.EditScheduledPostHandler)
+	mux.HandleFunc("/api/cancel-scheduled-post/", handlers.CancelScheduledPostHandler)
+	mux.HandleFunc("/api/pin-post/", handlers.PinPostHandler)
+	mux.HandleFunc("/api/unpin-post/", handlers.UnpinPostHandler)
+
+	mux.HandleFunc("/api/create-story", handlers.CreateStoryHandler)
+	mux.HandleFunc("/api/stories", handlers.GetStoriesHandler)
+	mux.HandleFunc("/api/user-stories/", handlers.GetUserStoriesHandler)
+	mux.HandleFunc("/api/view-story/", handlers.ViewStoryHandler)
+	mux.HandleFunc("/api/story-viewers/", handlers.GetStoryViewersHandler)
+	mux.HandleFunc("/api/delete-story/", handlers.DeleteStoryHandler)
+
+	mux.HandleFunc("/api/tag-posts/", handlers.GetTagPostsHandler)
+	mux.HandleFunc("/api/trending-tags", handlers.GetTrendingTagsHandler)
+	mux.HandleFunc("/api/search", handlers.SearchHandler)
+
+	mux.HandleFunc("/api/save-post/", handlers.SavePostHandler)
+	mux.HandleFunc("/api/unsave-post/", handlers.UnsavePostHandler)
+	mux.HandleFunc("/api/saved-posts", handlers.GetSavedPostsHandler)
+	mux.HandleFunc("/api/bookmark-collections", handlers.GetBookmarkCollectionsHandler)
+	mux.HandleFunc("/api/create-bookmark-collection", handlers.CreateBookmarkCollectionHandler)
+	mux.HandleFunc("/api/rename-bookmark-collection/", handlers.RenameBookmarkCollectionHandler)
+	mux.HandleFunc("/api/delete-bookmark-collection/", handlers.DeleteBookmarkCollectionHandler)
+
 	mux.HandleFunc("/api/create-event/", handlers.CreateEventHandler)
 	mux.HandleFunc("/api/get-events/", handlers.GetEventsHandler)
 	mux.HandleFunc("/api/update-attendees-status/", handlers.UpdateAttendeesStatus)
@@ -3,7 +3,7 @@ clear
 
 # Start backend
 cd backend
-go run main.go &
+go run -tags sqlite_fts5 main.go &
 
 cd ..
 # Start frontend